@@ -3,12 +3,15 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
-	appapi "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
 	"github.com/yafeiaa/argocd-game-tools/internal/argocd"
+	"github.com/yafeiaa/argocd-game-tools/internal/policy"
 )
 
 var appCmd = &cobra.Command{
@@ -16,68 +19,21 @@ var appCmd = &cobra.Command{
 	Short: "应用相关操作",
 }
 
-var appListCmd = &cobra.Command{
-	Use:   "list",
-	Short: "列出应用",
-	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-		defer cancel()
-		client, closer, err := argocd.NewClient(ctx, argocd.ClientConfig{
-			ServerAddr:  serverAddr,
-			Insecure:    insecure,
-			TLSNoVerify: tlsNoVerify,
-			Username:    username,
-			Password:    password,
-			AuthToken:   authToken,
-		})
-		if err != nil {
-			return err
-		}
-		defer closer()
-		list, err := client.ListApplications(ctx, &appapi.ApplicationQuery{})
-		if err != nil {
-			return err
-		}
-		for _, a := range list.Items {
-			fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%s\n", a.Name, a.Status.Sync.Status, a.Status.Health.Status)
-		}
-		return nil
-	},
-}
-
-var appGetCmd = &cobra.Command{
-	Use:   "get <name>",
-	Short: "获取应用详情",
-	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		name := args[0]
-		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-		defer cancel()
-		client, closer, err := argocd.NewClient(ctx, argocd.ClientConfig{
-			ServerAddr:  serverAddr,
-			Insecure:    insecure,
-			TLSNoVerify: tlsNoVerify,
-			Username:    username,
-			Password:    password,
-			AuthToken:   authToken,
-		})
-		if err != nil {
-			return err
-		}
-		defer closer()
-		app, err := client.GetApplication(ctx, name)
-		if err != nil {
-			return err
-		}
-		fmt.Fprintf(cmd.OutOrStdout(), "%s\nSync: %s\nHealth: %s\n", app.Name, app.Status.Sync.Status, app.Status.Health.Status)
-		return nil
-	},
-}
-
 var (
 	flagPrune  bool
 	flagDryRun bool
 	flagWait   time.Duration
+
+	syncRevision           string
+	syncResources          []string
+	syncStrategy           string
+	syncForce              bool
+	syncServerSide         bool
+	syncOptions            []string
+	syncRetryLimit         int64
+	syncRetryBackoff       time.Duration
+	syncApplyOutOfSyncOnly bool
+	syncWaitFor            []string
 )
 
 var appSyncCmd = &cobra.Command{
@@ -86,36 +42,92 @@ var appSyncCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
-		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		opts, err := syncOptionsFromFlags()
+		if err != nil {
+			return err
+		}
+		waitOpts, err := argocd.ParseWaitFor(syncWaitFor)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := operationContext()
 		defer cancel()
-		client, closer, err := argocd.NewClient(ctx, argocd.ClientConfig{
-			ServerAddr:  serverAddr,
-			Insecure:    insecure,
-			TLSNoVerify: tlsNoVerify,
-			Username:    username,
-			Password:    password,
-			AuthToken:   authToken,
-		})
+		client, closer, err := argocd.NewClient(ctx, clientConfig())
 		if err != nil {
 			return err
 		}
 		defer closer()
-		_, err = client.SyncApplication(ctx, name, flagPrune, flagDryRun, nil)
+		if flagPrune {
+			app, err := client.GetApplication(ctx, name)
+			if err != nil {
+				return err
+			}
+			if err := enforcePolicy(ctx, client, policy.Target{
+				App:        name,
+				Project:    app.Spec.Project,
+				DestServer: app.Spec.Destination.Server,
+				DestName:   app.Spec.Destination.Name,
+				Operation:  "sync --prune",
+				Workloads:  -1,
+			}); err != nil {
+				return err
+			}
+		}
+		// 试运行不修改集群状态，无需持锁
+		if !flagDryRun {
+			release, err := acquireAppLock(ctx, client, "", name, "sync")
+			if err != nil {
+				return err
+			}
+			defer release()
+		}
+		_, err = client.SyncApplication(ctx, name, opts)
 		if err != nil {
 			return err
 		}
+		fmt.Fprintln(cmd.OutOrStdout(), "sync requested")
 		if flagWait > 0 {
-			wctx, cancel := context.WithTimeout(context.Background(), flagWait)
-			defer cancel()
-			if err := client.WaitForHealthy(wctx, name, flagWait); err != nil {
+			// 等待时长独立于 --operation-timeout：只去掉操作 ctx 的截止时间，由 --wait 限制；
+			// Ctrl-C 结束等待（退出码 130），并照常释放维护锁
+			wctx, stop := signal.NotifyContext(context.WithoutCancel(ctx), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			waitOpts.Timeout = flagWait
+			if err := client.WaitForApp(wctx, name, waitOpts); err != nil {
+				if wctx.Err() != nil {
+					fmt.Fprintf(os.Stderr, "[wait] interrupted: %v\n", err)
+					return argocd.ErrInterrupted
+				}
 				return err
 			}
 		}
-		fmt.Fprintln(cmd.OutOrStdout(), "sync requested")
 		return nil
 	},
 }
 
+// syncOptionsFromFlags 由命令行参数构造同步参数
+func syncOptionsFromFlags() (argocd.SyncOptions, error) {
+	opts := argocd.SyncOptions{
+		Prune:              flagPrune,
+		DryRun:             flagDryRun,
+		Revision:           syncRevision,
+		Strategy:           syncStrategy,
+		Force:              syncForce,
+		Options:            syncOptions,
+		ServerSide:         syncServerSide,
+		ApplyOutOfSyncOnly: syncApplyOutOfSyncOnly,
+		RetryLimit:         syncRetryLimit,
+		RetryBackoff:       syncRetryBackoff,
+	}
+	for _, s := range syncResources {
+		r, err := argocd.ParseSyncResource(s)
+		if err != nil {
+			return opts, err
+		}
+		opts.Resources = append(opts.Resources, r)
+	}
+	return opts, opts.Validate()
+}
+
 func init() {
 	rootCmd.AddCommand(appCmd)
 	appCmd.AddCommand(appListCmd)
@@ -125,10 +137,32 @@ func init() {
 
 	appSyncCmd.Flags().BoolVar(&flagPrune, "prune", false, "允许删除不在期望状态的资源")
 	appSyncCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "仅试运行")
-	appSyncCmd.Flags().DurationVar(&flagWait, "wait", 0, "同步后等待健康的时间 (例如 60s)")
+	appSyncCmd.Flags().DurationVar(&flagWait, "wait", 0, "同步后等待的最长时间 (例如 60s)，条件由 --wait-for 指定")
+	appSyncCmd.Flags().StringSliceVar(&syncWaitFor, "wait-for", []string{"health", "sync", "operation"}, "--wait 等待的条件：health（Healthy）、sync（Synced）、operation（操作结束且成功）")
+	appSyncCmd.Flags().StringVar(&syncRevision, "revision", "", "同步到指定版本（分支、标签或提交），默认使用应用的 targetRevision")
+	appSyncCmd.Flags().StringArrayVar(&syncResources, "resource", nil, "只同步指定资源 GROUP:KIND:NAMESPACE/NAME（核心组 GROUP 留空，如 :Service:game/gate），可重复")
+	appSyncCmd.Flags().StringVar(&syncStrategy, "strategy", "", "同步策略：apply|hook（默认 hook）")
+	appSyncCmd.Flags().BoolVar(&syncForce, "force", false, "冲突时删除并重建资源（kubectl apply --force）")
+	appSyncCmd.Flags().BoolVar(&syncServerSide, "server-side", false, "使用 server-side apply（ServerSideApply=true）")
+	appSyncCmd.Flags().StringArrayVar(&syncOptions, "sync-option", nil, "附加同步选项 Key=value（如 Validate=false、CreateNamespace=true），可重复")
+	appSyncCmd.Flags().Int64Var(&syncRetryLimit, "retry-limit", 0, "同步失败后的重试次数，0 表示不重试")
+	appSyncCmd.Flags().DurationVar(&syncRetryBackoff, "retry-backoff", 5*time.Second, "重试的初始退避时间（与 --retry-limit 一起使用）")
+	appSyncCmd.Flags().BoolVar(&syncApplyOutOfSyncOnly, "apply-out-of-sync-only", false, "只 apply OutOfSync 的资源（ApplyOutOfSyncOnly=true）")
+	addLockFlags(appSyncCmd)
+	addPolicyFlags(appSyncCmd)
 
 	// down flags
 	appDownCmd.Flags().StringVar(&downProject, "project", "", "所属项目（用于资源过滤与权限校验）")
 	appDownCmd.Flags().BoolVar(&downNoGrace, "no-grace", false, "强制删除 Pod（立即或指定宽限期）")
 	appDownCmd.Flags().Int64Var(&downGracePeriod, "grace-period", 0, "Pod 删除宽限期秒数（与 --no-grace 联合使用）")
+	appDownCmd.Flags().BoolVarP(&downYes, "yes", "y", false, "跳过执行前的确认提示（用于自动化）")
+	appDownCmd.Flags().BoolVar(&downNonInteractive, "non-interactive", false, "标准输入不是终端时跳过确认提示")
+	appDownCmd.Flags().BoolVar(&downStrict, "strict", false, "预检出现 WARN 时也中止")
+	appDownCmd.Flags().DurationVar(&downWaitOperation, "wait-operation", 0, "确认并获取维护锁后，等待进行中的同步操作结束的最长时间")
+	appDownCmd.Flags().BoolVar(&downTerminateOperation, "terminate-operation", false, "确认并获取维护锁后终止进行中的同步操作（需要 sync 权限）")
+	appDownCmd.Flags().DurationVar(&downGuard, "guard", 0, "完成后持续监视副本是否回升的时长（例如 10m）")
+	appDownCmd.Flags().BoolVar(&downResume, "resume", false, "从上次中断（Ctrl-C 或出错）保存的检查点继续，跳过已完成的工作负载")
+	appDownCmd.Flags().BoolVar(&downGuardReapply, "guard-reapply", false, "守护期间发现副本回升时重新置 0")
+	addLockFlags(appDownCmd)
+	addPolicyFlags(appDownCmd)
 }
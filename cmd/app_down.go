@@ -3,11 +3,21 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
+	"unicode"
 
 	"github.com/spf13/cobra"
 
 	"github.com/yafeiaa/argocd-game-tools/internal/argocd"
+	"github.com/yafeiaa/argocd-game-tools/internal/config"
+	"github.com/yafeiaa/argocd-game-tools/internal/policy"
 )
 
 var appDownCmd = &cobra.Command{
@@ -16,34 +26,253 @@ var appDownCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		downProject = projectOrDefault(downProject)
+		ctx, cancel := operationContext()
 		defer cancel()
 
-		fmt.Printf("[down] preparing client server=%s insecure=%v tlsNoVerify=%v user=%s token=%v project=%s noGrace=%v grace=%d\n",
+		fmt.Fprintf(os.Stderr, "[down] preparing client server=%s insecure=%v tlsNoVerify=%v user=%s token=%v project=%s noGrace=%v grace=%d\n",
 			serverAddr, insecure, tlsNoVerify, username, authToken != "", downProject, downNoGrace, downGracePeriod)
 
-		client, closer, err := argocd.NewClient(ctx, argocd.ClientConfig{
-			ServerAddr:  serverAddr,
-			Insecure:    insecure,
-			TLSNoVerify: tlsNoVerify,
-			Username:    username,
-			Password:    password,
-			AuthToken:   authToken,
-			GRPCWeb:     grpcWeb,
-			GRPCWebRoot: grpcWebRoot,
-		})
+		client, closer, err := argocd.NewClient(ctx, clientConfig())
 		if err != nil {
 			return err
 		}
 		defer closer()
 
-		fmt.Printf("[down] client ready, start app=%s project=%s noGrace=%v grace=%d\n", name, downProject, downNoGrace, downGracePeriod)
-		return client.ScaleDownBySyncWave(ctx, downProject, name, downNoGrace, downGracePeriod)
+		// 保护策略最先评估：被拒绝时不做权限预检、状态预检，也不读取检查点
+		app, err := client.GetApplication(ctx, name)
+		if err != nil {
+			return err
+		}
+		if err := enforcePolicy(ctx, client, policy.Target{
+			App:        name,
+			Project:    app.Spec.Project,
+			DestServer: app.Spec.Destination.Server,
+			DestName:   app.Spec.Destination.Name,
+			Operation:  "down",
+			Workloads:  argocd.ScalableWorkloadCount(app),
+		}); err != nil {
+			return err
+		}
+
+		// 权限预检：避免执行到一半才发现 PatchResource/ResourceTree 无权限
+		perms, err := client.ScaleDownPermissions(ctx, downProject, name, downNoGrace, downTerminateOperation)
+		if err != nil {
+			return err
+		}
+		if err := client.CheckPermissions(ctx, perms); err != nil {
+			return err
+		}
+
+		opOpts := argocd.PreflightOptions{
+			WaitOperation:      downWaitOperation,
+			TerminateOperation: downTerminateOperation,
+		}
+		results, err := client.PreflightScaleDown(ctx, downProject, name, opOpts)
+		if err != nil {
+			return err
+		}
+		printPreflight(cmd.OutOrStdout(), results)
+		if argocd.PreflightFailed(results, downStrict) {
+			return fmt.Errorf("预检未通过（strict=%v），已中止", downStrict)
+		}
+
+		plan, err := client.PlanScaleDown(ctx, downProject, name)
+		if err != nil {
+			return err
+		}
+		ckptPath, err := checkpointPath(downProject, name)
+		if err != nil {
+			return err
+		}
+		var prev *argocd.Checkpoint
+		if downResume {
+			prev, err = argocd.LoadCheckpoint(ckptPath)
+			if err != nil {
+				return err
+			}
+			if prev == nil {
+				return fmt.Errorf("没有可继续的检查点 %s", ckptPath)
+			}
+			plan = argocd.ResumePlan(plan, prev)
+			fmt.Fprintf(os.Stderr, "[down] resuming from checkpoint %s (saved %s)\n", ckptPath, prev.UpdatedAt.Format(time.RFC3339))
+		}
+		printScaleDownPlan(cmd.OutOrStdout(), plan)
+		if err := confirmScaleDown(cmd, plan); err != nil {
+			return err
+		}
+		// 确认之后才接管 Ctrl-C：第一次中断在当前波次 Patch 完成后停止，第二次立即中止
+		ctx, stop, cleanup := interruptible(ctx)
+		defer cleanup()
+		release, err := acquireAppLock(ctx, client, downProject, name, "down")
+		if err != nil {
+			return err
+		}
+		defer release()
+		// 等待/终止进行中的操作会影响他人的同步，放在策略、确认与维护锁之后
+		if err := client.SettleOperation(ctx, downProject, name, opOpts); err != nil {
+			return interruptCause(ctx, err)
+		}
+
+		fmt.Fprintf(os.Stderr, "[down] client ready, start app=%s project=%s noGrace=%v grace=%d\n", name, downProject, downNoGrace, downGracePeriod)
+		progress, err := client.ExecuteScaleDown(ctx, plan, argocd.ScaleDownOptions{
+			NoGrace:     downNoGrace,
+			GracePeriod: downGracePeriod,
+			Stop:        stop,
+		})
+		if prev != nil {
+			progress.MergeDone(prev)
+		}
+		if err != nil {
+			return saveDownCheckpoint(ctx, cmd.OutOrStdout(), client, ckptPath, progress, interruptCause(ctx, err))
+		}
+		if err := os.Remove(ckptPath); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "[down] remove checkpoint failed: %v\n", err)
+		}
+		if downGuard <= 0 {
+			return nil
+		}
+		// 守护阶段不再需要“完成当前波次”的两段式中断：卸下 down 的信号处理，
+		// 改为 Ctrl-C 直接结束守护（仍会释放维护锁）。守护时长独立于 down 本身的超时。
+		cleanup()
+		guardCtx, stopGuard := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stopGuard()
+		drifts, err := client.GuardScaledDown(guardCtx, plan, argocd.GuardOptions{
+			Duration: downGuard,
+			Reapply:  downGuardReapply,
+		})
+		if err != nil {
+			if guardCtx.Err() != nil {
+				fmt.Fprintf(os.Stderr, "[guard] interrupted, %d drift(s) detected\n", len(drifts))
+				return argocd.ErrInterrupted
+			}
+			return err
+		}
+		if len(drifts) > 0 && !downGuardReapply {
+			return fmt.Errorf("守护期间检测到 %d 次副本回升", len(drifts))
+		}
+		return nil
 	},
 }
 
 var (
-	downProject     string
-	downNoGrace     bool
-	downGracePeriod int64
+	downProject        string
+	downNoGrace        bool
+	downGracePeriod    int64
+	downYes            bool
+	downNonInteractive bool
+
+	downStrict             bool
+	downWaitOperation      time.Duration
+	downTerminateOperation bool
+
+	downGuard        time.Duration
+	downGuardReapply bool
+
+	downResume bool
 )
+
+// checkpointPath 返回 down 检查点文件路径：~/.config/agt/checkpoints/<server>/<project>_<app>.json
+func checkpointPath(project, app string) (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "checkpoints", safeFileName(sessionServer()), safeFileName(project+"_"+app)+".json"), nil
+}
+
+// safeFileName 将服务地址等转换为可用作文件名的字符串
+func safeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// checkpointRefreshTimeout 保存检查点前回读副本数的时限
+const checkpointRefreshTimeout = 30 * time.Second
+
+// saveDownCheckpoint 在 down 未完成（中断或出错）时回读实际副本数、输出每个工作负载是否已置 0，
+// 并写入检查点供 --resume 继续；返回原错误
+func saveDownCheckpoint(ctx context.Context, w io.Writer, client *argocd.Client, path string, cp *argocd.Checkpoint, cause error) error {
+	// ctx 可能已被第二次 Ctrl-C 或 --operation-timeout 取消，回读使用独立的 ctx
+	rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), checkpointRefreshTimeout)
+	defer cancel()
+	client.RefreshReplicas(rctx, cp)
+	var zero, nonZero []argocd.WorkloadProgress
+	for _, wl := range cp.Workloads {
+		if wl.AtZero() {
+			zero = append(zero, wl)
+		} else {
+			nonZero = append(nonZero, wl)
+		}
+	}
+	printProgress := func(title string, list []argocd.WorkloadProgress) {
+		fmt.Fprintf(w, "%s (%d):\n", title, len(list))
+		for _, wl := range list {
+			replicas := "?"
+			if wl.Replicas != nil {
+				replicas = strconv.FormatInt(*wl.Replicas, 10)
+			}
+			fmt.Fprintf(w, "  wave=%d %s %s/%s replicas=%s state=%s\n", wl.SyncWave, wl.Kind, wl.Namespace, wl.Name, replicas, wl.State)
+		}
+	}
+	printProgress("Scaled to 0", zero)
+	printProgress("Not scaled to 0", nonZero)
+
+	cp.Server = sessionServer()
+	if err := argocd.SaveCheckpoint(path, cp); err != nil {
+		fmt.Fprintf(w, "[down] save checkpoint failed: %v\n", err)
+		return cause
+	}
+	fmt.Fprintf(w, "[down] checkpoint saved to %s, continue with: agt app down %s --resume\n", path, cp.App)
+	return cause
+}
+
+// printPreflight 输出预检清单
+func printPreflight(w io.Writer, results []argocd.CheckResult) {
+	fmt.Fprintln(w, "Pre-flight checks:")
+	for _, r := range results {
+		fmt.Fprintf(w, "  [%s] %-18s %s\n", r.Status, r.Name, r.Message)
+	}
+}
+
+// printScaleDownPlan 输出执行前的波次计划预览
+func printScaleDownPlan(w io.Writer, plan *argocd.ScaleDownPlan) {
+	fmt.Fprintf(w, "Application:  %s\n", plan.AppName)
+	fmt.Fprintf(w, "Project:      %s\n", plan.Project)
+	fmt.Fprintf(w, "Destination:  %s\n", plan.Destination())
+	fmt.Fprintf(w, "Namespaces:   %v\n", plan.Namespaces)
+	fmt.Fprintf(w, "Workloads:    %d\n", plan.WorkloadCount())
+	fmt.Fprintf(w, "Total pods:   %d\n", plan.TotalPods)
+	for _, wave := range plan.Waves {
+		fmt.Fprintf(w, "  wave=%d\n", wave.SyncWave)
+		for _, wl := range wave.Workloads {
+			r := wl.Resource
+			fmt.Fprintf(w, "    %s %s/%s pods=%d\n", r.Kind, r.Namespace, r.Name, wl.Pods)
+		}
+	}
+}
+
+// confirmScaleDown 要求操作者回输应用名确认；--yes 或（非 TTY 且 --non-interactive）时跳过
+func confirmScaleDown(cmd *cobra.Command, plan *argocd.ScaleDownPlan) error {
+	if downYes {
+		return nil
+	}
+	if !stdinIsTerminal() {
+		if downNonInteractive {
+			return nil
+		}
+		return fmt.Errorf("标准输入不是终端，无法确认；请使用 --yes 或 --non-interactive")
+	}
+	answer, err := promptLine(os.Stdin, cmd.OutOrStdout(), fmt.Sprintf("请输入应用名 %q 以确认缩容: ", plan.AppName))
+	if err != nil {
+		return err
+	}
+	if answer != plan.AppName {
+		return fmt.Errorf("确认失败：输入 %q 与应用名 %q 不一致，已取消", answer, plan.AppName)
+	}
+	return nil
+}
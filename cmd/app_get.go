@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/spf13/cobra"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/argocd"
+)
+
+var (
+	getOutput   string
+	getShowTree bool
+)
+
+var appGetCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "获取应用详情",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if getOutput != "" {
+			if err := checkOutputFormat(getOutput, "json", "yaml"); err != nil {
+				return err
+			}
+		}
+		ctx, cancel := operationContext()
+		defer cancel()
+		client, closer, err := argocd.NewClient(ctx, clientConfig())
+		if err != nil {
+			return err
+		}
+		defer closer()
+		out := cmd.OutOrStdout()
+		if getOutput != "" {
+			app, err := client.GetApplication(ctx, name)
+			if err != nil {
+				return err
+			}
+			return printStructured(out, getOutput, app)
+		}
+		detail, err := client.DescribeApplication(ctx, name)
+		if err != nil {
+			return err
+		}
+		if err := printAppDetail(out, detail); err != nil {
+			return err
+		}
+		if getShowTree {
+			fmt.Fprintln(out)
+			printResourceTree(out, detail.Tree)
+		}
+		return nil
+	},
+}
+
+// printAppDetail 输出应用概要、同步策略、状态条件、最近一次操作与按 SyncWave 分组的受管资源
+func printAppDetail(w io.Writer, d *argocd.AppDetail) error {
+	app := d.App
+	src := app.Spec.GetSource()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Name:\t%s\n", app.Name)
+	fmt.Fprintf(tw, "Project:\t%s\n", app.Spec.Project)
+	fmt.Fprintf(tw, "Cluster:\t%s\n", destinationCluster(app.Spec.Destination))
+	fmt.Fprintf(tw, "Namespace:\t%s\n", orDash(app.Spec.Destination.Namespace))
+	fmt.Fprintf(tw, "Repo:\t%s\n", orDash(src.RepoURL))
+	if src.Chart != "" {
+		fmt.Fprintf(tw, "Chart:\t%s\n", src.Chart)
+	} else {
+		fmt.Fprintf(tw, "Path:\t%s\n", orDash(src.Path))
+	}
+	fmt.Fprintf(tw, "Target:\t%s\n", orDash(src.TargetRevision))
+	fmt.Fprintf(tw, "Sync Policy:\t%s\n", syncPolicy(app.Spec.SyncPolicy))
+	if p := app.Spec.SyncPolicy; p != nil && len(p.SyncOptions) > 0 {
+		fmt.Fprintf(tw, "Sync Options:\t%s\n", strings.Join(p.SyncOptions, ","))
+	}
+	fmt.Fprintf(tw, "Sync Status:\t%s (%s)\n", app.Status.Sync.Status, orDash(shortRevision(app.Status.Sync.Revision)))
+	health := string(app.Status.Health.Status)
+	if app.Status.Health.Message != "" {
+		health += " (" + app.Status.Health.Message + ")"
+	}
+	fmt.Fprintf(tw, "Health Status:\t%s\n", health)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(app.Status.Conditions) > 0 {
+		fmt.Fprintln(w, "\nConditions:")
+		for _, c := range app.Status.Conditions {
+			at := ""
+			if c.LastTransitionTime != nil {
+				at = " (" + c.LastTransitionTime.Format(time.RFC3339) + ")"
+			}
+			fmt.Fprintf(w, "  %s: %s%s\n", c.Type, c.Message, at)
+		}
+	}
+
+	if op := app.Status.OperationState; op != nil {
+		fmt.Fprintln(w, "\nOperation:")
+		fmt.Fprintf(w, "  Phase:     %s\n", op.Phase)
+		if op.Message != "" {
+			fmt.Fprintf(w, "  Message:   %s\n", op.Message)
+		}
+		fmt.Fprintf(w, "  Started:   %s\n", op.StartedAt.Format(time.RFC3339))
+		if op.FinishedAt != nil {
+			fmt.Fprintf(w, "  Finished:  %s\n", op.FinishedAt.Format(time.RFC3339))
+		}
+	}
+
+	fmt.Fprintln(w, "\nResources:")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "WAVE\tKIND\tNAMESPACE\tNAME\tSYNC\tHEALTH\tREPLICAS")
+	for _, wave := range d.Waves {
+		for _, r := range wave.Resources {
+			res := r.Resource
+			health := "-"
+			if res.Health != nil && res.Health.Status != "" {
+				health = string(res.Health.Status)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				wave.SyncWave, res.Kind, orDash(res.Namespace), res.Name, res.Status, health, replicasColumn(r))
+		}
+	}
+	return tw.Flush()
+}
+
+// syncPolicy 同步策略概要
+func syncPolicy(p *appv1.SyncPolicy) string {
+	if p == nil || p.Automated == nil {
+		return "Manual"
+	}
+	return fmt.Sprintf("Automated (prune=%v, selfHeal=%v)", p.Automated.Prune, p.Automated.SelfHeal)
+}
+
+// replicasColumn 可缩容工作负载显示 ready/spec 副本数与 Pod 数，其余资源为 -
+func replicasColumn(r argocd.ResourceDetail) string {
+	if !r.Scalable {
+		return "-"
+	}
+	if r.Replicas == nil {
+		return fmt.Sprintf("? (pods=%d)", r.Pods)
+	}
+	ready := int64(0)
+	if r.ReadyReplicas != nil {
+		ready = *r.ReadyReplicas
+	}
+	return fmt.Sprintf("%d/%d (pods=%d)", ready, *r.Replicas, r.Pods)
+}
+
+func shortRevision(rev string) string {
+	if len(rev) > 7 {
+		return rev[:7]
+	}
+	return rev
+}
+
+// printResourceTree 按父子关系输出资源树，每个节点附带健康状态
+func printResourceTree(w io.Writer, tree *appv1.ApplicationTree) {
+	fmt.Fprintln(w, "Resource tree:")
+	key := func(r appv1.ResourceRef) string { return r.Group + "/" + r.Kind + "/" + r.Namespace + "/" + r.Name }
+	inTree := map[string]bool{}
+	for _, n := range tree.Nodes {
+		inTree[key(n.ResourceRef)] = true
+	}
+	children := map[string][]appv1.ResourceNode{}
+	var roots []appv1.ResourceNode
+	for _, n := range tree.Nodes {
+		isRoot := true
+		for _, p := range n.ParentRefs {
+			if inTree[key(p)] {
+				children[key(p)] = append(children[key(p)], n)
+				isRoot = false
+			}
+		}
+		if isRoot {
+			roots = append(roots, n)
+		}
+	}
+	sortNodes := func(nodes []appv1.ResourceNode) {
+		sort.Slice(nodes, func(i, j int) bool {
+			if nodes[i].Kind != nodes[j].Kind {
+				return nodes[i].Kind < nodes[j].Kind
+			}
+			return nodes[i].Name < nodes[j].Name
+		})
+	}
+	var walk func(nodes []appv1.ResourceNode, prefix string)
+	walk = func(nodes []appv1.ResourceNode, prefix string) {
+		sortNodes(nodes)
+		for i, n := range nodes {
+			branch, next := "├── ", "│   "
+			if i == len(nodes)-1 {
+				branch, next = "└── ", "    "
+			}
+			health := ""
+			if n.Health != nil && n.Health.Status != "" {
+				health = " [" + string(n.Health.Status) + "]"
+			}
+			fmt.Fprintf(w, "%s%s%s %s/%s%s\n", prefix, branch, n.Kind, orDash(n.Namespace), n.Name, health)
+			walk(children[key(n.ResourceRef)], prefix+next)
+		}
+	}
+	walk(roots, "")
+}
+
+func init() {
+	appGetCmd.Flags().StringVarP(&getOutput, "output", "o", "", "输出格式：json|yaml（默认输出可读的详情）")
+	appGetCmd.Flags().BoolVar(&getShowTree, "show-tree", false, "输出资源树（工作负载 → ReplicaSet → Pod）及各节点健康状态")
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/spf13/cobra"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/argocd"
+)
+
+var (
+	listOutput   string
+	listProjects []string
+	listSelector string
+	listSync     string
+	listHealth   string
+	listCluster  string
+)
+
+var appListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出应用",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkOutputFormat(listOutput, "table", "wide", "json", "yaml", "name"); err != nil {
+			return err
+		}
+		ctx, cancel := operationContext()
+		defer cancel()
+		client, closer, err := argocd.NewClient(ctx, clientConfig())
+		if err != nil {
+			return err
+		}
+		defer closer()
+		apps, err := client.FindApplications(ctx, argocd.AppFilter{
+			Projects: listProjects,
+			Selector: listSelector,
+			Sync:     listSync,
+			Health:   listHealth,
+			Cluster:  listCluster,
+		})
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		switch listOutput {
+		case "json", "yaml":
+			return printStructured(out, listOutput, apps)
+		case "name":
+			for _, a := range apps {
+				fmt.Fprintln(out, a.Name)
+			}
+			return nil
+		}
+		return printAppTable(out, apps, listOutput == "wide")
+	},
+}
+
+// printAppTable 以表格输出应用列表；wide 额外输出项目、目标、仓库、版本与最近一次操作状态
+func printAppTable(w io.Writer, apps []appv1.Application, wide bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if wide {
+		fmt.Fprintln(tw, "NAME\tPROJECT\tCLUSTER\tNAMESPACE\tSYNC\tHEALTH\tREPO\tREVISION\tOPERATION")
+	} else {
+		fmt.Fprintln(tw, "NAME\tPROJECT\tSYNC\tHEALTH")
+	}
+	for _, a := range apps {
+		if !wide {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", a.Name, a.Spec.Project, a.Status.Sync.Status, a.Status.Health.Status)
+			continue
+		}
+		src := a.Spec.GetSource()
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			a.Name, a.Spec.Project, destinationCluster(a.Spec.Destination), orDash(a.Spec.Destination.Namespace),
+			a.Status.Sync.Status, a.Status.Health.Status, orDash(src.RepoURL), orDash(src.TargetRevision), operationPhase(&a))
+	}
+	return tw.Flush()
+}
+
+// destinationCluster 目标集群：优先显示地址，仅配置名称时显示名称
+func destinationCluster(dest appv1.ApplicationDestination) string {
+	if dest.Server != "" {
+		return dest.Server
+	}
+	return orDash(dest.Name)
+}
+
+// operationPhase 最近一次操作的阶段，从未操作时为 -
+func operationPhase(app *appv1.Application) string {
+	if app.Status.OperationState == nil {
+		return "-"
+	}
+	return string(app.Status.OperationState.Phase)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	appListCmd.Flags().StringVarP(&listOutput, "output", "o", "table", "输出格式：table|wide|json|yaml|name")
+	appListCmd.Flags().StringSliceVar(&listProjects, "project", nil, "仅列出指定项目的应用，可重复或以逗号分隔")
+	appListCmd.Flags().StringVarP(&listSelector, "selector", "l", "", "标签选择器，如 team=game,env!=dev")
+	appListCmd.Flags().StringVar(&listSync, "sync", "", "按同步状态过滤：Synced|OutOfSync|Unknown")
+	appListCmd.Flags().StringVar(&listHealth, "health", "", "按健康状态过滤：Healthy|Progressing|Degraded|Suspended|Missing|Unknown")
+	appListCmd.Flags().StringVar(&listCluster, "cluster", "", "按目标集群地址或名称过滤")
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/argocd"
+)
+
+var appLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "应用维护锁相关操作",
+}
+
+var appLockStatusCmd = &cobra.Command{
+	Use:   "status <name>",
+	Short: "查看应用维护锁",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		lockProject = projectOrDefault(lockProject)
+		ctx, cancel := operationContext()
+		defer cancel()
+		client, closer, err := argocd.NewClient(ctx, clientConfig())
+		if err != nil {
+			return err
+		}
+		defer closer()
+		lease, err := client.GetLock(ctx, lockProject, name)
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		if lease == nil {
+			fmt.Fprintf(out, "%s: unlocked\n", name)
+			return nil
+		}
+		state := "held"
+		if lease.Expired(time.Now()) {
+			state = "expired"
+		}
+		fmt.Fprintf(out, "%s: %s\nOwner:     %s\nOperation: %s\nStarted:   %s\n",
+			name, state, lease.Owner, lease.Operation, lease.StartedAt.Format(time.RFC3339))
+		if !lease.RenewedAt.IsZero() {
+			fmt.Fprintf(out, "Renewed:   %s\n", lease.RenewedAt.Format(time.RFC3339))
+		}
+		fmt.Fprintf(out, "TTL:       %s\nExpires:   %s\n", time.Duration(lease.TTL), lease.ExpiresAt().Format(time.RFC3339))
+		return nil
+	},
+}
+
+var appLockReleaseCmd = &cobra.Command{
+	Use:   "release <name>",
+	Short: "释放应用维护锁",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		lockProject = projectOrDefault(lockProject)
+		ctx, cancel := operationContext()
+		defer cancel()
+		client, closer, err := argocd.NewClient(ctx, clientConfig())
+		if err != nil {
+			return err
+		}
+		defer closer()
+		lease, err := client.GetLock(ctx, lockProject, name)
+		if err != nil && (!lockForce || lease == nil) {
+			return err
+		}
+		if lease == nil {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s: unlocked\n", name)
+			return nil
+		}
+		if err == nil && !lease.Expired(time.Now()) && !lockForce {
+			return fmt.Errorf("锁仍由 %s 持有（%s），如确认释放请使用 --force", lease.Owner, lease.Operation)
+		}
+		// 只释放上面检查过的锁：期间被他人重新获取或续期时不删除
+		if err := client.ReleaseLock(ctx, lockProject, name, lease); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s: released\n", name)
+		return nil
+	},
+}
+
+var (
+	lockProject string
+	lockForce   bool
+	lockSteal   bool
+	lockTTL     time.Duration
+)
+
+// acquireAppLock 为维护操作获取应用锁，并在持有期间每隔 TTL/3 续期；
+// 返回的 release 应在操作结束后调用，它停止续期并释放仍属于本次操作的锁
+func acquireAppLock(ctx context.Context, client *argocd.Client, project, name, operation string) (func(), error) {
+	lease, err := client.AcquireLock(ctx, project, name, operation, lockTTL, lockSteal)
+	if err != nil {
+		return nil, err
+	}
+	renewCtx, stopRenew := context.WithCancel(context.Background())
+	kept := make(chan *argocd.Lease, 1)
+	go func() { kept <- client.KeepLock(renewCtx, project, name, lease) }()
+	return func() {
+		stopRenew()
+		lease := <-kept
+		if lease == nil {
+			return
+		}
+		// 原 ctx 可能已超时或取消，释放锁使用新的操作 ctx
+		rctx, cancel := operationContext()
+		defer cancel()
+		if err := client.ReleaseLock(rctx, project, name, lease); err != nil {
+			fmt.Fprintf(os.Stderr, "[lock] %v, not released\n", err)
+		}
+	}, nil
+}
+
+// addLockFlags 为需要持锁的命令注册 --steal-lock/--lock-ttl
+func addLockFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&lockSteal, "steal-lock", false, "接管他人持有且未过期的维护锁")
+	cmd.Flags().DurationVar(&lockTTL, "lock-ttl", time.Hour, "维护锁有效期，持有期间每隔 TTL/3 自动续期；进程退出未释放时超时后他人可直接获取")
+}
+
+func init() {
+	appCmd.AddCommand(appLockCmd)
+	appLockCmd.AddCommand(appLockStatusCmd)
+	appLockCmd.AddCommand(appLockReleaseCmd)
+
+	appLockCmd.PersistentFlags().StringVar(&lockProject, "project", "", "所属项目")
+	appLockReleaseCmd.Flags().BoolVar(&lockForce, "force", false, "即使锁未过期也强制释放")
+}
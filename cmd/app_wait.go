@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/argocd"
+)
+
+var (
+	waitHealth       []string
+	waitSync         bool
+	waitOperation    bool
+	waitScaledToZero bool
+	waitPodsReady    bool
+	waitSelector     string
+	waitProjects     []string
+	waitTimeout      time.Duration
+)
+
+var appWaitCmd = &cobra.Command{
+	Use:   "wait [name...]",
+	Short: "等待应用达到指定状态（不触发同步）",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && waitSelector == "" && len(waitProjects) == 0 {
+			return fmt.Errorf("请指定应用名，或使用 --selector/--project 选择应用")
+		}
+		opts := argocd.WaitOptions{
+			Sync:                  waitSync,
+			Operation:             waitOperation,
+			WorkloadsScaledToZero: waitScaledToZero,
+			PodsReady:             waitPodsReady,
+			Timeout:               waitTimeout,
+		}
+		for _, s := range waitHealth {
+			h, err := argocd.ParseHealth(s)
+			if err != nil {
+				return err
+			}
+			opts.Health = append(opts.Health, h)
+		}
+		if opts.Empty() {
+			return fmt.Errorf("请至少指定一个等待条件：--health、--sync、--operation、--workloads-scaled-to-zero 或 --pods-ready")
+		}
+
+		ctx, cancel := operationContext()
+		defer cancel()
+		client, closer, err := argocd.NewClient(ctx, clientConfig())
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		names := map[string]bool{}
+		for _, n := range args {
+			names[n] = true
+		}
+		if waitSelector != "" || len(waitProjects) > 0 {
+			apps, err := client.FindApplications(ctx, argocd.AppFilter{Projects: waitProjects, Selector: waitSelector})
+			if err != nil {
+				return err
+			}
+			if len(apps) == 0 {
+				return fmt.Errorf("没有匹配 --selector/--project 的应用")
+			}
+			for _, a := range apps {
+				names[a.Name] = true
+			}
+		}
+		list := make([]string, 0, len(names))
+		for n := range names {
+			list = append(list, n)
+		}
+		sort.Strings(list)
+		fmt.Fprintf(os.Stderr, "[wait] waiting for %d app(s): %v\n", len(list), list)
+
+		// 任一应用失败或超时即结束全部等待
+		g, gctx := errgroup.WithContext(ctx)
+		for _, name := range list {
+			name := name
+			g.Go(func() error {
+				return client.WaitForApp(gctx, name, opts)
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%d app(s) ready\n", len(list))
+		return nil
+	},
+}
+
+func init() {
+	appCmd.AddCommand(appWaitCmd)
+	appWaitCmd.Flags().StringSliceVar(&waitHealth, "health", nil, "可接受的健康状态，可重复或以逗号分隔：Healthy|Progressing|Degraded|Suspended|Missing|Unknown")
+	appWaitCmd.Flags().BoolVar(&waitSync, "sync", false, "等待 Synced")
+	appWaitCmd.Flags().BoolVar(&waitOperation, "operation", false, "等待没有进行中的操作，且最近一次操作成功")
+	appWaitCmd.Flags().BoolVar(&waitScaledToZero, "workloads-scaled-to-zero", false, "等待全部可缩容工作负载副本数为 0 且 Pod 已删除")
+	appWaitCmd.Flags().BoolVar(&waitPodsReady, "pods-ready", false, "等待应用的全部 Pod 就绪")
+	appWaitCmd.Flags().StringVarP(&waitSelector, "selector", "l", "", "按标签选择应用，如 team=game")
+	appWaitCmd.Flags().StringSliceVar(&waitProjects, "project", nil, "等待指定项目下的全部应用")
+	appWaitCmd.Flags().DurationVar(&waitTimeout, "timeout", 0, "每个应用的最长等待时间，0 表示只受 --operation-timeout 限制")
+}
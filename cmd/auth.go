@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/argocd"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "认证与权限相关操作",
+}
+
+var authCanICmd = &cobra.Command{
+	Use:   "can-i [<action> <resource> [<subresource>]]",
+	Short: "检查当前 token 的权限；使用 --app 时执行与 app down 相同的预检",
+	Example: `  agt auth can-i get applications default/demo-app
+  agt auth can-i --app demo-app --project default --no-grace`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if canIApp != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.RangeArgs(2, 3)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := operationContext()
+		defer cancel()
+		client, closer, err := argocd.NewClient(ctx, clientConfig())
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if canIApp != "" {
+			perms, err := client.ScaleDownPermissions(ctx, projectOrDefault(canIProject), canIApp, canINoGrace, canITerminate)
+			if err != nil {
+				return err
+			}
+			if err := client.CheckPermissions(ctx, perms); err != nil {
+				if argocd.KindOf(err) == argocd.KindPermissionDenied {
+					fmt.Fprintln(cmd.OutOrStdout(), "no")
+				}
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "yes")
+			return nil
+		}
+
+		p := argocd.Permission{Action: args[0], Resource: args[1]}
+		if len(args) == 3 {
+			p.Subresource = args[2]
+		}
+		ok, err := client.CanI(ctx, p)
+		if err != nil {
+			return err
+		}
+		// 与 kubectl auth can-i 一致：结果输出到 stdout，无权限时以 PermissionDenied 的退出码结束
+		if !ok {
+			fmt.Fprintln(cmd.OutOrStdout(), "no")
+			return &argocd.MissingPermissionsError{Missing: []argocd.Permission{p}}
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "yes")
+		return nil
+	},
+}
+
+var (
+	canIApp       string
+	canIProject   string
+	canINoGrace   bool
+	canITerminate bool
+)
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authCanICmd)
+
+	authCanICmd.Flags().StringVar(&canIApp, "app", "", "检查 app down 该应用所需的全部权限")
+	authCanICmd.Flags().StringVar(&canIProject, "project", "", "应用所属项目（与 --app 搭配）")
+	authCanICmd.Flags().BoolVar(&canINoGrace, "no-grace", false, "同时检查 --no-grace 所需的 delete 权限（与 --app 搭配）")
+	authCanICmd.Flags().BoolVar(&canITerminate, "terminate-operation", false, "同时检查 --terminate-operation 所需的 sync 权限（与 --app 搭配）")
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import "testing"
+
+func TestAuthCanI(t *testing.T) {
+	addr := startFakeArgoCD(t)
+	cases := []struct {
+		action string
+		stdout string
+		code   int
+	}{
+		{"get", "yes\n", exitOK},
+		{"delete", "no\n", exitPermissionDenied},
+	}
+	for _, tc := range cases {
+		stdout, err := runAgt(t, "auth", "can-i", tc.action, "applications", "default/game-a", "--server", addr, "--insecure")
+		if stdout != tc.stdout {
+			t.Errorf("can-i %s: stdout = %q, want %q", tc.action, stdout, tc.stdout)
+		}
+		if code := exitCode(err); code != tc.code {
+			t.Errorf("can-i %s: exit code = %d (%v), want %d", tc.action, code, err, tc.code)
+		}
+	}
+}
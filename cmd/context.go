@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/config"
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "管理命名的 Argo CD 连接上下文",
+	// 管理上下文本身时不应用当前上下文
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+}
+
+var contextListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出上下文",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CURRENT\tNAME\tSERVER\tGRPC-WEB\tPROJECT")
+		for _, c := range cfg.Contexts {
+			cur := ""
+			if c.Name == cfg.CurrentContext {
+				cur = "*"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%v\t%s\n", cur, c.Name, contextServer(&c), c.GRPCWeb, c.Project)
+		}
+		return w.Flush()
+	},
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "切换当前上下文",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if _, ok := cfg.Context(args[0]); !ok {
+			return fmt.Errorf("上下文 %q 不存在", args[0])
+		}
+		cfg.CurrentContext = args[0]
+		if err := cfg.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "switched to context %q\n", args[0])
+		return nil
+	},
+}
+
+var contextAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "新增或覆盖上下文（连接参数取自全局参数 --server/--grpc-web 等）",
+	Example: `  agt context add sh --server argocd-sh.example.com:443 --grpc-web --project games --token-env ARGOCD_SH_TOKEN
+  agt context add hk --server argocd-hk.example.com:443 --username ops --password-env ARGOCD_HK_PASSWORD`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if serverAddr == "" && !portForward && portForwardNamespace == "" {
+			return fmt.Errorf("必须指定 --server 或 --port-forward")
+		}
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		cfg.SetContext(config.Context{
+			Name:                 args[0],
+			Server:               serverAddr,
+			Insecure:             insecure,
+			TLSNoVerify:          tlsNoVerify,
+			GRPCWeb:              grpcWeb,
+			GRPCWebRoot:          grpcWebRoot,
+			ServerCAFile:         serverCAFile,
+			ClientCert:           clientCert,
+			ClientKey:            clientKey,
+			Headers:              extraHeaders,
+			ProxyURL:             proxyURL,
+			PortForward:          portForward || portForwardNamespace != "",
+			PortForwardNamespace: portForwardNamespace,
+			KubeContext:          kubeContext,
+			Project:              contextAddProject,
+			Credentials: config.Credentials{
+				TokenEnv:    contextAddTokenEnv,
+				TokenFile:   contextAddTokenFile,
+				Username:    username,
+				PasswordEnv: contextAddPasswordEnv,
+			},
+		})
+		if cfg.CurrentContext == "" {
+			cfg.CurrentContext = args[0]
+		}
+		if err := cfg.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "context %q saved to %s\n", args[0], cfg.Path())
+		return nil
+	},
+}
+
+var contextDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "删除上下文",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if !cfg.DeleteContext(args[0]) {
+			return fmt.Errorf("上下文 %q 不存在", args[0])
+		}
+		if err := cfg.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "context %q deleted\n", args[0])
+		return nil
+	},
+}
+
+var (
+	contextAddProject     string
+	contextAddTokenEnv    string
+	contextAddTokenFile   string
+	contextAddPasswordEnv string
+)
+
+// loadConfig 读取 agt 配置文件
+func loadConfig() (*config.Config, error) {
+	path, err := config.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return config.Load(path)
+}
+
+// applyContext 将选中上下文的值填充到未通过参数或环境变量指定的全局参数中（flag > env > context）
+func applyContext(cmd *cobra.Command) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	name := contextName
+	if name == "" {
+		name = cfg.CurrentContext
+	}
+	if name == "" {
+		return nil
+	}
+	c, ok := cfg.Context(name)
+	if !ok {
+		return fmt.Errorf("上下文 %q 不存在（配置文件 %s）", name, cfg.Path())
+	}
+	// 上下文的 token、请求头、代理与证书只用于它自己的服务端：显式指定的 --server（或端口转发）
+	// 与上下文不一致时不应用该上下文，上下文也不会替换显式指定的连接目标
+	if target, ok := explicitTarget(); ok && !contextTargets(c) {
+		if contextName != "" {
+			return fmt.Errorf("上下文 %q 连接 %s，与指定的 %s 不一致", name, contextServer(c), target)
+		}
+		fmt.Fprintf(os.Stderr, "[context] %s differs from current context %q (%s), not applying it\n", target, name, contextServer(c))
+		return nil
+	}
+	flags := cmd.Flags()
+	// 字符串参数的默认值来自环境变量，非空即表示已由 flag 或 env 指定
+	if serverAddr == "" {
+		serverAddr = c.Server
+	}
+	if grpcWebRoot == "" {
+		grpcWebRoot = c.GRPCWebRoot
+	}
+	if serverCAFile == "" {
+		serverCAFile = c.ServerCAFile
+	}
+	if clientCert == "" && clientKey == "" {
+		clientCert, clientKey = c.ClientCert, c.ClientKey
+	}
+	if !flags.Changed("header") {
+		extraHeaders = c.Headers
+	}
+	if proxyURL == "" {
+		proxyURL = c.ProxyURL
+	}
+	if !flags.Changed("port-forward") {
+		portForward = c.PortForward
+	}
+	if portForwardNamespace == "" {
+		portForwardNamespace = c.PortForwardNamespace
+	}
+	if kubeContext == "" {
+		kubeContext = c.KubeContext
+	}
+	if !flags.Changed("insecure") {
+		insecure = c.Insecure
+	}
+	if !flags.Changed("tls-no-verify") {
+		tlsNoVerify = c.TLSNoVerify
+	}
+	if !flags.Changed("grpc-web") {
+		grpcWeb = c.GRPCWeb
+	}
+	defaultProject = c.Project
+	if authToken == "" && username == "" {
+		token, err := c.Credentials.Token()
+		if err != nil {
+			return err
+		}
+		authToken = token
+		if authToken == "" && c.Credentials.Username != "" {
+			username = c.Credentials.Username
+			if password == "" {
+				password = c.Credentials.Password()
+			}
+		}
+	}
+	return nil
+}
+
+// explicitTarget 返回由参数或环境变量显式指定的连接目标
+func explicitTarget() (string, bool) {
+	switch {
+	case serverAddr != "":
+		return "--server " + serverAddr, true
+	case portForward || portForwardNamespace != "":
+		return "port-forward:" + portForwardNamespace, true
+	}
+	return "", false
+}
+
+// contextTargets 判断上下文是否指向显式指定的连接目标
+func contextTargets(c *config.Context) bool {
+	if serverAddr != "" {
+		return !c.PortForward && c.Server == serverAddr
+	}
+	return c.PortForward && (portForwardNamespace == "" || portForwardNamespace == c.PortForwardNamespace)
+}
+
+// contextServer 上下文的连接目标，用于提示
+func contextServer(c *config.Context) string {
+	if c.PortForward {
+		return "port-forward:" + c.PortForwardNamespace
+	}
+	return c.Server
+}
+
+// projectOrDefault 未指定 --project 时使用上下文中的默认项目
+func projectOrDefault(project string) string {
+	if project == "" {
+		return defaultProject
+	}
+	return project
+}
+
+func init() {
+	rootCmd.AddCommand(contextCmd)
+	contextCmd.AddCommand(contextListCmd)
+	contextCmd.AddCommand(contextUseCmd)
+	contextCmd.AddCommand(contextAddCmd)
+	contextCmd.AddCommand(contextDeleteCmd)
+
+	contextAddCmd.Flags().StringVar(&contextAddProject, "project", "", "该上下文的默认项目")
+	contextAddCmd.Flags().StringVar(&contextAddTokenEnv, "token-env", "", "从该环境变量读取 token")
+	contextAddCmd.Flags().StringVar(&contextAddTokenFile, "token-file", "", "从该文件读取 token")
+	contextAddCmd.Flags().StringVar(&contextAddPasswordEnv, "password-env", "", "从该环境变量读取密码（与 --username 搭配）")
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/config"
+)
+
+// withContexts 写入包含 sh（直连）与 pf（端口转发）两个上下文的配置，并在测试结束时恢复全局连接参数
+func withContexts(t *testing.T, current string) {
+	t.Helper()
+	t.Setenv("AGT_CONFIG", filepath.Join(t.TempDir(), "agt.yaml"))
+	t.Setenv("SH_TOKEN", "sh-token")
+	t.Setenv("PF_TOKEN", "pf-token")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.SetContext(config.Context{
+		Name:        "sh",
+		Server:      "argocd-sh.example.com:443",
+		Headers:     []string{"X-Gateway-Token=secret"},
+		ProxyURL:    "http://proxy:3128",
+		Project:     "games",
+		Credentials: config.Credentials{TokenEnv: "SH_TOKEN"},
+	})
+	cfg.SetContext(config.Context{
+		Name:                 "pf",
+		PortForward:          true,
+		PortForwardNamespace: "argocd",
+		Credentials:          config.Credentials{TokenEnv: "PF_TOKEN"},
+	})
+	cfg.CurrentContext = current
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := []*string{&contextName, &serverAddr, &authToken, &username, &password, &proxyURL, &portForwardNamespace, &defaultProject}
+	values := make([]string, len(saved))
+	for i, p := range saved {
+		values[i] = *p
+		*p = ""
+	}
+	headers, pf := extraHeaders, portForward
+	extraHeaders, portForward = nil, false
+	t.Cleanup(func() {
+		for i, p := range saved {
+			*p = values[i]
+		}
+		extraHeaders, portForward = headers, pf
+	})
+}
+
+func TestApplyContextTarget(t *testing.T) {
+	cmd := &cobra.Command{}
+
+	// 未指定 --server：应用当前上下文
+	withContexts(t, "sh")
+	if err := applyContext(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if serverAddr != "argocd-sh.example.com:443" || authToken != "sh-token" || proxyURL != "http://proxy:3128" || len(extraHeaders) != 1 || defaultProject != "games" {
+		t.Errorf("context not applied: server=%q token=%q proxy=%q headers=%v project=%q", serverAddr, authToken, proxyURL, extraHeaders, defaultProject)
+	}
+
+	// --server 与上下文一致：应用
+	withContexts(t, "sh")
+	serverAddr = "argocd-sh.example.com:443"
+	if err := applyContext(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if authToken != "sh-token" {
+		t.Errorf("matching --server: token = %q, want sh-token", authToken)
+	}
+
+	// --server 指向其他服务端：不使用当前上下文的 token、请求头、代理
+	withContexts(t, "sh")
+	serverAddr = "argocd-hk.example.com:443"
+	if err := applyContext(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if serverAddr != "argocd-hk.example.com:443" || authToken != "" || proxyURL != "" || extraHeaders != nil || defaultProject != "" {
+		t.Errorf("other --server: server=%q token=%q proxy=%q headers=%v project=%q", serverAddr, authToken, proxyURL, extraHeaders, defaultProject)
+	}
+
+	// 端口转发上下文不能替换显式的 --server
+	withContexts(t, "pf")
+	serverAddr = "argocd-hk.example.com:443"
+	if err := applyContext(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if portForward || portForwardNamespace != "" || authToken != "" {
+		t.Errorf("port-forward context applied over --server: portForward=%v namespace=%q token=%q", portForward, portForwardNamespace, authToken)
+	}
+
+	// 显式端口转发：只应用端口转发上下文
+	withContexts(t, "sh")
+	portForward = true
+	if err := applyContext(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if serverAddr != "" || authToken != "" {
+		t.Errorf("server context applied over --port-forward: server=%q token=%q", serverAddr, authToken)
+	}
+	withContexts(t, "pf")
+	portForward = true
+	if err := applyContext(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if portForwardNamespace != "argocd" || authToken != "pf-token" {
+		t.Errorf("port-forward context: namespace=%q token=%q", portForwardNamespace, authToken)
+	}
+
+	// 显式 --context 与 --server 冲突时报错
+	withContexts(t, "")
+	contextName = "sh"
+	serverAddr = "argocd-hk.example.com:443"
+	if err := applyContext(cmd); err == nil {
+		t.Error("--context sh with a different --server accepted")
+	}
+}
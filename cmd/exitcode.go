@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/argocd"
+	"github.com/yafeiaa/argocd-game-tools/internal/policy"
+)
+
+// 退出码：供脚本按错误类型分支处理，README 中有对应说明
+const (
+	exitOK               = 0
+	exitError            = 1
+	exitNotFound         = 3
+	exitPermissionDenied = 4
+	exitUnauthenticated  = 5
+	exitUnavailable      = 6
+	exitTLS              = 7
+	exitTimeout          = 8
+	exitConflict         = 9
+	// exitInterrupted 与 shell 对 SIGINT 的约定一致（128+2）
+	exitInterrupted = 130
+)
+
+// exitCode 将错误映射为退出码
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	if errors.Is(err, argocd.ErrInterrupted) {
+		return exitInterrupted
+	}
+	var denied *policy.DeniedError
+	if errors.As(err, &denied) {
+		return exitPermissionDenied
+	}
+	switch argocd.KindOf(err) {
+	case argocd.KindNotFound:
+		return exitNotFound
+	case argocd.KindPermissionDenied:
+		return exitPermissionDenied
+	case argocd.KindUnauthenticated:
+		return exitUnauthenticated
+	case argocd.KindUnavailable:
+		return exitUnavailable
+	case argocd.KindTLS:
+		return exitTLS
+	case argocd.KindTimeout:
+		return exitTimeout
+	case argocd.KindConflict:
+		return exitConflict
+	default:
+		return exitError
+	}
+}
@@ -1,54 +1,176 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
-	"time"
+	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/yafeiaa/argocd-game-tools/internal/argocd"
+	"github.com/yafeiaa/argocd-game-tools/internal/config"
+	"github.com/yafeiaa/argocd-game-tools/internal/sso"
 )
 
 var loginCmd = &cobra.Command{
 	Use:   "login",
-	Short: "登录并验证与 Argo CD 的连接",
+	Short: "登录并验证与 Argo CD 的连接，用户名密码登录获得的 token 会保存供后续命令复用",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if serverAddr == "" {
-			return fmt.Errorf("必须指定 --server 或设置 ARGOCD_SERVER")
+		if serverAddr == "" && argocdContext == "" {
+			fmt.Fprintf(os.Stderr, "[login] no --server given, falling back to argocd CLI config\n")
 		}
 
-		fmt.Printf("[login] preparing client server=%s insecure=%v tlsNoVerify=%v user=%s token=%v\n",
+		fmt.Fprintf(os.Stderr, "[login] preparing client server=%s insecure=%v tlsNoVerify=%v user=%s token=%v\n",
 			serverAddr, insecure, tlsNoVerify, username, authToken != "")
 
-		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		if loginSSO {
+			return runSSOLogin(cmd)
+		}
+
+		ctx, cancel := operationContext()
 		defer cancel()
 
-		client, closer, err := argocd.NewClient(ctx, argocd.ClientConfig{
-			ServerAddr:  serverAddr,
-			Insecure:    insecure,
-			TLSNoVerify: tlsNoVerify,
-			Username:    username,
-			Password:    password,
-			AuthToken:   authToken,
-			GRPCWeb:     grpcWeb,
-			GRPCWebRoot: grpcWebRoot,
-		})
+		cfg := clientConfig()
+		// 提供了用户名时总是重新登录，而不是复用已保存的 token
+		if username != "" && authToken == "" {
+			cfg.AuthToken = ""
+		}
+		client, closer, err := argocd.NewClient(ctx, cfg)
 		if err != nil {
 			return err
 		}
 		defer closer()
 
-		// 试探性调用：获取版本或项目列表确认连通
+		// Version 服务无需认证，再用 UserInfo 确认 token 有效
 		ver, err := client.Version(ctx)
 		if err != nil {
 			return err
 		}
-		fmt.Fprintf(cmd.OutOrStdout(), "[login] connected: %s\n", ver)
+		info, err := client.UserInfo(ctx)
+		if err != nil {
+			return err
+		}
+		if !info.LoggedIn {
+			return &argocd.Error{Kind: argocd.KindUnauthenticated, Op: "login", Err: fmt.Errorf("未登录：请提供 --auth-token 或 --username/--password")}
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "[login] connected: server=%s user=%s\n", ver.Version, info.Username)
+		warnIncompatible(ver.Version)
 		return nil
 	},
 }
 
+// runSSOLogin 通过 Argo CD 配置的 OIDC 提供方（授权码 + PKCE）登录并保存 id_token
+func runSSOLogin(cmd *cobra.Command) error {
+	if sessionServer() == "" {
+		return fmt.Errorf("--sso 需要指定 --server 或 --port-forward")
+	}
+	ctx, cancel := operationContext()
+	defer cancel()
+
+	cfg := clientConfig()
+	cfg.AuthToken = ""
+	cfg.Username = ""
+	cfg.Password = ""
+	client, closer, err := argocd.NewClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	oauth2conf, httpClient, err := client.SSOConfig(ctx)
+	if err != nil {
+		return err
+	}
+	opts := sso.Options{
+		Config:     oauth2conf,
+		Port:       ssoPort,
+		HTTPClient: httpClient,
+		Out:        cmd.OutOrStdout(),
+	}
+	if ssoLaunchBrowser {
+		opts.OpenURL = sso.OpenBrowser
+	}
+	res, err := sso.Login(ctx, opts)
+	if err != nil {
+		return &argocd.Error{Kind: argocd.KindUnauthenticated, Op: "sso", Err: err}
+	}
+
+	// 用新 token 验证连通
+	cfg.AuthToken = res.IDToken
+	cfg.OnToken = nil
+	verified, vcloser, err := argocd.NewClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer vcloser()
+	info, err := verified.UserInfo(ctx)
+	if err != nil {
+		return err
+	}
+	if !info.LoggedIn {
+		return &argocd.Error{Kind: argocd.KindUnauthenticated, Op: "sso", Err: fmt.Errorf("服务器未接受 SSO token")}
+	}
+
+	conf, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	conf.SetSession(config.Session{Server: sessionServer(), Username: info.Username, AuthToken: res.IDToken})
+	if err := conf.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "[login] sso login as %s, session token saved to %s\n", info.Username, conf.Path())
+	return nil
+}
+
+// storedSessionToken 返回 agt 配置中该服务地址的会话 token；
+// 指定了 user 而会话属于其他用户（或未记录用户）时不复用，改由调用方以该用户登录
+func storedSessionToken(server, user string) string {
+	if server == "" {
+		return ""
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return ""
+	}
+	s, ok := cfg.Session(server)
+	if !ok {
+		return ""
+	}
+	if user != "" && s.Username != user {
+		fmt.Fprintf(os.Stderr, "[login] stored session belongs to %q, not %q; ignoring it\n", s.Username, user)
+		return ""
+	}
+	return s.AuthToken
+}
+
+// saveSessionToken 将新获得的 token 保存到 agt 配置（文件权限 0600）
+func saveSessionToken(token string) {
+	server := sessionServer()
+	if server == "" {
+		return
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[login] load config failed, token not saved: %v\n", err)
+		return
+	}
+	cfg.SetSession(config.Session{Server: server, Username: username, AuthToken: token})
+	if err := cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "[login] save token failed: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "[login] session token saved to %s\n", cfg.Path())
+}
+
+var (
+	loginSSO         bool
+	ssoPort          int
+	ssoLaunchBrowser bool
+)
+
 func init() {
+	loginCmd.Flags().BoolVar(&loginSSO, "sso", false, "通过 Argo CD 配置的 SSO（OIDC 授权码 + PKCE）在浏览器中登录")
+	loginCmd.Flags().IntVar(&ssoPort, "sso-port", sso.DefaultPort, "SSO 登录本地回调端口（需与 OIDC 客户端注册的回调地址一致）")
+	loginCmd.Flags().BoolVar(&ssoLaunchBrowser, "sso-launch-browser", true, "自动打开浏览器；为 false 时仅打印登录地址")
 	rootCmd.AddCommand(loginCmd)
 }
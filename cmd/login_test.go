@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/config"
+)
+
+func TestStoredSessionTokenUser(t *testing.T) {
+	t.Setenv("AGT_CONFIG", filepath.Join(t.TempDir(), "agt.yaml"))
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.SetSession(config.Session{Server: "argocd.example.com", Username: "alice", AuthToken: "alice-token"})
+	cfg.SetSession(config.Session{Server: "sso.example.com", AuthToken: "sso-token"})
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		server, user, want string
+	}{
+		{"argocd.example.com", "", "alice-token"},
+		{"argocd.example.com", "alice", "alice-token"},
+		{"argocd.example.com", "bob", ""},
+		{"sso.example.com", "", "sso-token"},
+		{"sso.example.com", "bob", ""},
+		{"other.example.com", "", ""},
+	}
+	for _, tc := range cases {
+		if got := storedSessionToken(tc.server, tc.user); got != tc.want {
+			t.Errorf("storedSessionToken(%q, %q) = %q, want %q", tc.server, tc.user, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	accountpkg "github.com/argoproj/argo-cd/v2/pkg/apiclient/account"
+	applicationpkg "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	versionpkg "github.com/argoproj/argo-cd/v2/pkg/apiclient/version"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// fakeArgoCD 本地明文 gRPC 服务，提供 Application 与 Version 服务的最小实现
+type fakeArgoCD struct {
+	applicationpkg.UnimplementedApplicationServiceServer
+	versionpkg.UnimplementedVersionServiceServer
+	accountpkg.UnimplementedAccountServiceServer
+	apps []appv1.Application
+}
+
+func (f *fakeArgoCD) List(ctx context.Context, q *applicationpkg.ApplicationQuery) (*appv1.ApplicationList, error) {
+	return &appv1.ApplicationList{Items: f.apps}, nil
+}
+
+func (f *fakeArgoCD) Get(ctx context.Context, q *applicationpkg.ApplicationQuery) (*appv1.Application, error) {
+	for i := range f.apps {
+		if q.Name != nil && f.apps[i].Name == *q.Name {
+			return &f.apps[i], nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "application %q not found", q.GetName())
+}
+
+// CanI 只允许 get
+func (f *fakeArgoCD) CanI(ctx context.Context, req *accountpkg.CanIRequest) (*accountpkg.CanIResponse, error) {
+	if req.Action == "get" {
+		return &accountpkg.CanIResponse{Value: "yes"}, nil
+	}
+	return &accountpkg.CanIResponse{Value: "no"}, nil
+}
+
+func (f *fakeArgoCD) Version(ctx context.Context, _ *emptypb.Empty) (*versionpkg.VersionMessage, error) {
+	return &versionpkg.VersionMessage{Version: "v2.14.17+fake"}, nil
+}
+
+// startFakeArgoCD 启动 fakeArgoCD 并返回监听地址，测试结束时停止
+func startFakeArgoCD(t *testing.T, apps ...appv1.Application) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	fake := &fakeArgoCD{apps: apps}
+	applicationpkg.RegisterApplicationServiceServer(srv, fake)
+	versionpkg.RegisterVersionServiceServer(srv, fake)
+	accountpkg.RegisterAccountServiceServer(srv, fake)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func fakeApp(name, project string) appv1.Application {
+	return appv1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "argocd"},
+		Spec: appv1.ApplicationSpec{
+			Project:     project,
+			Destination: appv1.ApplicationDestination{Server: "https://kubernetes.default.svc", Namespace: name},
+		},
+	}
+}
+
+// runAgt 以隔离的配置目录执行 agt 命令，返回写到进程标准输出的全部内容。
+// 直接捕获 os.Stdout 而非 cmd.SetOut，这样诊断日志误写到标准输出时也会被发现。
+func runAgt(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("AGT_CONFIG", filepath.Join(home, "agt.yaml"))
+	t.Setenv("ARGOCD_CONFIG_DIR", filepath.Join(home, "argocd"))
+	for _, k := range []string{"ALL_PROXY", "all_proxy", "HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+		t.Setenv(k, "")
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+
+	rootCmd.SetArgs(args)
+	err = rootCmd.Execute()
+
+	os.Stdout = stdout
+	w.Close()
+	return <-out, err
+}
+
+func TestAppListJSONOutput(t *testing.T) {
+	addr := startFakeArgoCD(t, fakeApp("game-a", "prod"), fakeApp("game-b", "prod"))
+	stdout, err := runAgt(t, "app", "list", "-o", "json", "--server", addr, "--insecure")
+	if err != nil {
+		t.Fatalf("app list: %v", err)
+	}
+	var apps []appv1.Application
+	if err := json.Unmarshal([]byte(stdout), &apps); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout)
+	}
+	if len(apps) != 2 || apps[0].Name != "game-a" || apps[1].Name != "game-b" {
+		t.Errorf("apps = %+v", apps)
+	}
+}
+
+func TestAppGetStructuredOutput(t *testing.T) {
+	addr := startFakeArgoCD(t, fakeApp("game-a", "prod"))
+	for _, format := range []string{"json", "yaml"} {
+		stdout, err := runAgt(t, "app", "get", "game-a", "-o", format, "--server", addr, "--insecure")
+		if err != nil {
+			t.Fatalf("app get -o %s: %v", format, err)
+		}
+		var app appv1.Application
+		if err := yaml.UnmarshalStrict([]byte(stdout), &app); err != nil {
+			t.Fatalf("-o %s: stdout does not parse: %v\n%s", format, err, stdout)
+		}
+		if app.Name != "game-a" || app.Spec.Project != "prod" {
+			t.Errorf("-o %s: app = %+v", format, app)
+		}
+	}
+}
+
+func TestVersionJSONOutput(t *testing.T) {
+	addr := startFakeArgoCD(t)
+	stdout, err := runAgt(t, "version", "-o", "json", "--server", addr, "--insecure")
+	if err != nil {
+		t.Fatalf("version: %v", err)
+	}
+	var v struct {
+		Client map[string]string          `json:"client"`
+		Server *versionpkg.VersionMessage `json:"server"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &v); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout)
+	}
+	if v.Server == nil || v.Server.Version != "v2.14.17+fake" || v.Client["version"] == "" {
+		t.Errorf("version = %+v", v)
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/argocd"
+	"github.com/yafeiaa/argocd-game-tools/internal/audit"
+	"github.com/yafeiaa/argocd-game-tools/internal/policy"
+)
+
+var (
+	policyFile     string
+	overridePolicy bool
+	justification  string
+)
+
+// enforcePolicy 在执行受保护操作前评估策略；被拒绝时仅在 --override-policy 且提供理由时放行，并写入审计日志
+func enforcePolicy(ctx context.Context, client *argocd.Client, target policy.Target) error {
+	if overridePolicy && strings.TrimSpace(justification) == "" {
+		return fmt.Errorf("--override-policy 需要通过 --justification 提供理由")
+	}
+	p, file, err := policy.Load(policyFile)
+	if err != nil {
+		return err
+	}
+	if len(p.Rules) == 0 {
+		return nil
+	}
+	info, err := client.UserInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("获取当前用户失败，无法评估策略: %w", err)
+	}
+	target.Subject = info.Username
+	fmt.Fprintf(os.Stderr, "[policy] evaluating %s for app=%s subject=%s\n", file, target.App, target.Subject)
+	return applyPolicy(p, target, time.Now())
+}
+
+// applyPolicy 按 now 评估策略；拒绝时若指定了 --override-policy 则写入审计日志后放行
+func applyPolicy(p *policy.Policy, target policy.Target, now time.Time) error {
+	err := p.Check(target, now)
+	var denied *policy.DeniedError
+	if !errors.As(err, &denied) {
+		return err
+	}
+	if !overridePolicy {
+		return denied
+	}
+	var details []string
+	for _, v := range denied.Violations {
+		details = append(details, fmt.Sprintf("[%s] %s", v.Rule, v.Reason))
+	}
+	fmt.Fprintf(os.Stderr, "[policy] overriding %d violation(s): %s\n", len(details), justification)
+	if err := audit.Record(audit.Entry{
+		Subject:       target.Subject,
+		Server:        serverAddr,
+		App:           target.App,
+		Project:       target.Project,
+		Operation:     target.Operation,
+		Event:         "policy-override",
+		Justification: justification,
+		Details:       details,
+	}); err != nil {
+		return fmt.Errorf("写入审计日志失败，拒绝覆盖策略: %w", err)
+	}
+	return nil
+}
+
+// addPolicyFlags 为受策略保护的命令注册 --override-policy/--justification
+func addPolicyFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&overridePolicy, "override-policy", false, "忽略保护策略的拒绝（需配合 --justification）")
+	cmd.Flags().StringVar(&justification, "justification", "", "覆盖策略的理由，将写入审计日志")
+}
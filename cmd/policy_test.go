@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/audit"
+	"github.com/yafeiaa/argocd-game-tools/internal/policy"
+)
+
+func TestApplyPolicyOverride(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "audit.log")
+	t.Setenv("AGT_AUDIT_LOG", log)
+	defer func(o bool, j string) { overridePolicy, justification = o, j }(overridePolicy, justification)
+
+	p := &policy.Policy{Rules: []policy.Rule{{Name: "prod", Apps: []string{"game-*"}, AllowedSubjects: []string{"alice"}}}}
+	target := policy.Target{App: "game-a", Project: "prod", Subject: "bob", Operation: "down", Workloads: 2}
+
+	overridePolicy, justification = false, ""
+	var denied *policy.DeniedError
+	if err := applyPolicy(p, target, time.Now()); !errors.As(err, &denied) {
+		t.Fatalf("without override: error = %v, want *policy.DeniedError", err)
+	}
+	if exitCode(denied) != exitPermissionDenied {
+		t.Errorf("exit code = %d, want %d", exitCode(denied), exitPermissionDenied)
+	}
+	if _, err := os.Stat(log); !os.IsNotExist(err) {
+		t.Fatalf("audit log written without override: %v", err)
+	}
+
+	overridePolicy, justification = true, "incident 42"
+	if err := applyPolicy(p, target, time.Now()); err != nil {
+		t.Fatalf("with override: %v", err)
+	}
+	b, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e audit.Entry
+	if err := json.Unmarshal(b, &e); err != nil {
+		t.Fatalf("audit entry %q: %v", b, err)
+	}
+	if e.Event != "policy-override" || e.Subject != "bob" || e.App != "game-a" || e.Project != "prod" ||
+		e.Operation != "down" || e.Justification != "incident 42" || len(e.Details) != 1 {
+		t.Errorf("audit entry = %+v", e)
+	}
+
+	// 未违反策略时不写审计日志
+	if err := os.Remove(log); err != nil {
+		t.Fatal(err)
+	}
+	target.Subject = "alice"
+	if err := applyPolicy(p, target, time.Now()); err != nil {
+		t.Fatalf("allowed subject: %v", err)
+	}
+	if _, err := os.Stat(log); !os.IsNotExist(err) {
+		t.Errorf("audit log written for an allowed operation: %v", err)
+	}
+}
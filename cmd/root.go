@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/argocd"
 )
 
 var (
@@ -16,6 +20,31 @@ var (
 	authToken   string
 	grpcWeb     bool
 	grpcWebRoot string
+
+	serverCAFile string
+	clientCert   string
+	clientKey    string
+
+	trustOnFirstUse bool
+
+	extraHeaders []string
+	proxyURL     string
+
+	portForward          bool
+	portForwardNamespace string
+	kubeContext          string
+
+	apiRetry = argocd.DefaultRetryPolicy
+
+	requestTimeout   time.Duration
+	operationTimeout time.Duration
+
+	argocdConfig  string
+	argocdContext string
+
+	contextName string
+	// defaultProject 来自当前上下文，命令未指定 --project 时使用
+	defaultProject string
 )
 
 // rootCmd is the base command
@@ -24,17 +53,81 @@ var rootCmd = &cobra.Command{
 	Short:         "Argo CD gRPC 封装 CLI",
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return applyContext(cmd)
+	},
+}
+
+// clientConfig 由全局参数构造 Argo CD 客户端配置。
+// 未指定 --auth-token 时复用 agt login 保存的会话 token（--username 与会话用户一致时），获得新 token 时写回配置文件。
+func clientConfig() argocd.ClientConfig {
+	retry := apiRetry
+	token := authToken
+	if token == "" {
+		token = storedSessionToken(sessionServer(), username)
+	}
+	return argocd.ClientConfig{
+		ServerAddr:  serverAddr,
+		Insecure:    insecure,
+		TLSNoVerify: tlsNoVerify,
+		Username:    username,
+		Password:    password,
+		AuthToken:   token,
+		OnToken:     saveSessionToken,
+		GRPCWeb:     grpcWeb,
+		GRPCWebRoot: grpcWebRoot,
+		Retry:       &retry,
+
+		RequestTimeout: requestTimeout,
+
+		ServerCAFile:   serverCAFile,
+		ClientCertFile: clientCert,
+		ClientKeyFile:  clientKey,
+
+		Headers:  extraHeaders,
+		ProxyURL: proxyURL,
+
+		PortForward:          portForward,
+		PortForwardNamespace: portForwardNamespace,
+		KubeContext:          kubeContext,
+
+		PinnedFingerprint: pinnedFingerprint(serverAddr),
+		TrustOnFirstUse:   trustOnFirstUse,
+		ConfirmCert:       confirmCert,
+		OnPin:             savePinnedFingerprint,
+
+		ArgoCDConfig:  argocdConfig,
+		ArgoCDContext: argocdContext,
+	}
+}
+
+// sessionServer 返回保存会话 token 使用的服务标识；端口转发时按命名空间区分
+func sessionServer() string {
+	if portForward || portForwardNamespace != "" {
+		return "port-forward://" + portForwardNamespace
+	}
+	return serverAddr
+}
+
+// operationContext 返回整个命令操作使用的 ctx，受 --operation-timeout 限制（0 表示不限）；
+// 单次 API 调用的超时由 Client 按 --request-timeout 自行设置
+func operationContext() (context.Context, context.CancelFunc) {
+	if operationTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), operationTimeout)
 }
 
 // Execute runs the root command
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&contextName, "context", os.Getenv("AGT_CONTEXT"), "使用 agt 配置文件中的命名上下文（默认为 current-context）")
 	rootCmd.PersistentFlags().StringVar(&serverAddr, "server", os.Getenv("ARGOCD_SERVER"), "Argo CD API server 地址 (host:port)")
 	rootCmd.PersistentFlags().BoolVar(&insecure, "insecure", false, "允许明文/不安全连接（开发环境）")
 	rootCmd.PersistentFlags().BoolVar(&tlsNoVerify, "tls-no-verify", false, "跳过 TLS 证书校验")
@@ -43,4 +136,22 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&authToken, "auth-token", os.Getenv("ARGOCD_AUTH_TOKEN"), "Bearer Token（优先于用户名密码）")
 	rootCmd.PersistentFlags().BoolVar(&grpcWeb, "grpc-web", false, "启用 grpc-web 代理模式（避免直连 gRPC 阻塞）")
 	rootCmd.PersistentFlags().StringVar(&grpcWebRoot, "grpc-web-root-path", "", "grpc-web 根路径（经由反向代理时使用，如 /api")
+	rootCmd.PersistentFlags().StringVar(&serverCAFile, "server-ca-file", os.Getenv("ARGOCD_SERVER_CA_FILE"), "校验服务端证书的 CA 文件（PEM，用于内部签发的证书）")
+	rootCmd.PersistentFlags().StringVar(&clientCert, "client-cert", os.Getenv("ARGOCD_CLIENT_CERT"), "mTLS 客户端证书文件（PEM，与 --client-key 搭配）")
+	rootCmd.PersistentFlags().StringVar(&clientKey, "client-key", os.Getenv("ARGOCD_CLIENT_KEY"), "mTLS 客户端私钥文件（PEM，与 --client-cert 搭配）")
+	rootCmd.PersistentFlags().StringArrayVar(&extraHeaders, "header", nil, "每个请求附加的请求头 Name=value，可重复指定（如经由网关时的 X-Gateway-Token）")
+	rootCmd.PersistentFlags().StringVar(&proxyURL, "proxy-url", "", "经由代理连接 Argo CD（http://、https:// 为 HTTP CONNECT，socks5://）")
+	rootCmd.PersistentFlags().BoolVar(&portForward, "port-forward", false, "不直连 server，使用 kubeconfig 端口转发到 argocd-server Pod 后连接")
+	rootCmd.PersistentFlags().StringVar(&portForwardNamespace, "port-forward-namespace", os.Getenv("ARGOCD_NAMESPACE"), "端口转发时 argocd-server 所在命名空间（默认 kubeconfig 当前命名空间，指定即启用端口转发）")
+	rootCmd.PersistentFlags().StringVar(&kubeContext, "kube-context", "", "端口转发使用的 kubeconfig 上下文（默认当前上下文）")
+	rootCmd.PersistentFlags().BoolVar(&trustOnFirstUse, "trust-on-first-use", false, "证书未通过校验时信任当前证书并在 agt 配置中固定其指纹，之后的连接按指纹校验")
+	rootCmd.PersistentFlags().StringVar(&argocdConfig, "argocd-config", os.Getenv("ARGOCD_CONFIG"), "官方 argocd CLI 配置文件路径（默认 ~/.config/argocd/config）")
+	rootCmd.PersistentFlags().StringVar(&argocdContext, "argocd-context", "", "使用 argocd CLI 配置中的指定上下文（复用 argocd login 的 token）")
+	rootCmd.PersistentFlags().DurationVar(&requestTimeout, "request-timeout", 30*time.Second, "单次 API 调用的超时（0 表示不限），超时的幂等调用按重试策略重试")
+	rootCmd.PersistentFlags().DurationVar(&operationTimeout, "operation-timeout", 30*time.Minute, "整个命令操作（如一次 down 的全部波次）的超时（0 表示不限）")
+	rootCmd.PersistentFlags().IntVar(&apiRetry.MaxAttempts, "api-retries", apiRetry.MaxAttempts, "幂等 API 调用遇到 Unavailable/超时时的最大尝试次数（1 表示不重试）")
+	rootCmd.PersistentFlags().DurationVar(&apiRetry.InitialBackoff, "api-retry-backoff", apiRetry.InitialBackoff, "API 重试的初始退避时间（指数增长并带抖动）")
+	rootCmd.PersistentFlags().DurationVar(&apiRetry.MaxBackoff, "api-retry-max-backoff", apiRetry.MaxBackoff, "API 重试单次退避上限")
+	rootCmd.PersistentFlags().DurationVar(&apiRetry.MaxElapsed, "api-retry-budget", apiRetry.MaxElapsed, "单次 API 操作的重试总时长预算（0 表示不限）")
+	rootCmd.PersistentFlags().StringVar(&policyFile, "policy-file", os.Getenv("AGT_POLICY_FILE"), "保护策略文件（默认依次查找 ./.agt-policy.yaml、~/.config/agt/policy.yaml）")
 }
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/argocd"
+)
+
+// interruptible 从 parent 派生可中断的 ctx，处理 Ctrl-C：
+// 第一次 SIGINT/SIGTERM 关闭返回的 stop，由命令在安全点停止；第二次立即取消 ctx（cause 为 argocd.ErrInterrupted）。
+// 返回的 cleanup 恢复默认的信号行为。
+func interruptible(parent context.Context) (context.Context, <-chan struct{}, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	stop := make(chan struct{})
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigCh:
+			fmt.Fprintln(os.Stderr, "\n[signal] interrupt received: finishing current wave, press Ctrl-C again to abort immediately")
+			close(stop)
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case <-sigCh:
+			fmt.Fprintln(os.Stderr, "\n[signal] second interrupt received: aborting")
+			cancel(argocd.ErrInterrupted)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, stop, func() {
+		signal.Stop(sigCh)
+		cancel(nil)
+	}
+}
+
+// interruptCause ctx 因第二次中断被取消时把 err 换成 argocd.ErrInterrupted（退出码 130），
+// 否则原样返回 err
+func interruptCause(ctx context.Context, err error) error {
+	if err != nil && errors.Is(context.Cause(ctx), argocd.ErrInterrupted) {
+		return argocd.ErrInterrupted
+	}
+	return err
+}
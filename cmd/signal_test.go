@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/argocd"
+)
+
+func TestInterruptible(t *testing.T) {
+	ctx, stop, cleanup := interruptible(context.Background())
+	defer cleanup()
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-stop:
+	case <-time.After(5 * time.Second):
+		t.Fatal("first interrupt did not close stop")
+	}
+	if ctx.Err() != nil {
+		t.Fatal("first interrupt cancelled ctx")
+	}
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("second interrupt did not cancel ctx")
+	}
+	// 被取消的 API 调用返回 context.Canceled，应按中断处理
+	if err := interruptCause(ctx, ctx.Err()); !errors.Is(err, argocd.ErrInterrupted) || exitCode(err) != exitInterrupted {
+		t.Errorf("interruptCause = %v (exit %d), want ErrInterrupted (exit %d)", err, exitCode(err), exitInterrupted)
+	}
+
+	other, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := interruptCause(other, other.Err()); !errors.Is(err, context.Canceled) {
+		t.Errorf("interruptCause without a signal = %v, want context.Canceled", err)
+	}
+}
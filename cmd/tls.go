@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/config"
+)
+
+// pinnedFingerprint 返回 agt 配置中该服务地址固定的证书指纹
+func pinnedFingerprint(server string) string {
+	if server == "" {
+		return ""
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return ""
+	}
+	if p, ok := cfg.PinnedCert(server); ok {
+		return p.Fingerprint
+	}
+	return ""
+}
+
+// savePinnedFingerprint 将信任的证书指纹写入 agt 配置
+func savePinnedFingerprint(fingerprint string) {
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[tls] load config failed, fingerprint not pinned: %v\n", err)
+		return
+	}
+	cfg.SetPinnedCert(config.PinnedCert{Server: serverAddr, Fingerprint: fingerprint})
+	if err := cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "[tls] save pinned fingerprint failed: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "[tls] certificate fingerprint pinned in %s\n", cfg.Path())
+}
+
+// confirmCert 证书未通过校验时展示证书信息并询问是否信任；非交互终端时不信任
+func confirmCert(server string, cert *x509.Certificate, fingerprint string) bool {
+	if !stdinIsTerminal() {
+		return false
+	}
+	out := os.Stderr
+	fmt.Fprintf(out, "服务端 %s 的证书未通过校验：\n", server)
+	fmt.Fprintf(out, "  Subject:  %s\n", cert.Subject)
+	fmt.Fprintf(out, "  Issuer:   %s\n", cert.Issuer)
+	fmt.Fprintf(out, "  DNS:      %s\n", strings.Join(cert.DNSNames, ", "))
+	fmt.Fprintf(out, "  有效期:   %s ~ %s\n", cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339))
+	fmt.Fprintf(out, "  SHA-256:  %s\n", fingerprint)
+	answer, err := promptLine(os.Stdin, out, "核对指纹无误后输入 yes 信任并固定该证书: ")
+	if err != nil {
+		return false
+	}
+	return answer == "yes"
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient/version"
+	"github.com/spf13/cobra"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/argocd"
+	"github.com/yafeiaa/argocd-game-tools/internal/buildinfo"
+)
+
+var (
+	versionClientOnly bool
+	versionOutput     string
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "显示客户端构建信息与 Argo CD 服务端版本",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if versionOutput != "" && versionOutput != "json" {
+			return fmt.Errorf("不支持的输出格式 %q（可选 json）", versionOutput)
+		}
+		clientInfo := buildinfo.Get()
+		var server *version.VersionMessage
+		if !versionClientOnly {
+			ctx, cancel := operationContext()
+			defer cancel()
+			client, closer, err := argocd.NewClient(ctx, clientConfig())
+			if err != nil {
+				return err
+			}
+			defer closer()
+			server, err = client.Version(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		out := cmd.OutOrStdout()
+		if versionOutput == "json" {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				Client buildinfo.Info          `json:"client"`
+				Server *version.VersionMessage `json:"server,omitempty"`
+			}{clientInfo, server})
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "agt:\t%s\n", clientInfo.Version)
+		fmt.Fprintf(w, "  GitCommit:\t%s\n", clientInfo.GitCommit)
+		fmt.Fprintf(w, "  BuildDate:\t%s\n", clientInfo.BuildDate)
+		fmt.Fprintf(w, "  GoVersion:\t%s\n", clientInfo.GoVersion)
+		fmt.Fprintf(w, "  Platform:\t%s\n", clientInfo.Platform)
+		fmt.Fprintf(w, "  ArgoCDClient:\t%s\n", clientInfo.ArgoCDVersion)
+		if server != nil {
+			fmt.Fprintf(w, "argocd-server:\t%s\n", server.Version)
+			fmt.Fprintf(w, "  BuildDate:\t%s\n", server.BuildDate)
+			fmt.Fprintf(w, "  GitCommit:\t%s\n", server.GitCommit)
+			fmt.Fprintf(w, "  Platform:\t%s\n", server.Platform)
+			fmt.Fprintf(w, "  Kustomize:\t%s\n", server.KustomizeVersion)
+			fmt.Fprintf(w, "  Helm:\t%s\n", server.HelmVersion)
+			fmt.Fprintf(w, "  Kubectl:\t%s\n", server.KubectlVersion)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if server != nil {
+			warnIncompatible(server.Version)
+		}
+		return nil
+	},
+}
+
+// warnIncompatible 服务端版本超出客户端验证范围时在 stderr 输出警告
+func warnIncompatible(serverVersion string) {
+	if msg := argocd.CompatibilityWarning(serverVersion); msg != "" {
+		fmt.Fprintf(os.Stderr, "[version] WARNING: %s\n", msg)
+	}
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionClientOnly, "client", false, "仅显示客户端信息，不连接服务端")
+	versionCmd.Flags().StringVarP(&versionOutput, "output", "o", "", "输出格式：json")
+	rootCmd.AddCommand(versionCmd)
+}
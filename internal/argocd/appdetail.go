@@ -0,0 +1,91 @@
+package argocd
+
+import (
+	"context"
+	"sort"
+
+	applications "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ResourceDetail 受管资源；可缩容工作负载附带副本数与 Pod 数
+type ResourceDetail struct {
+	Resource appv1.ResourceStatus
+	Scalable bool
+	// Replicas/ReadyReplicas 回读的 spec.replicas 与 status.readyReplicas，读取失败时为空
+	Replicas      *int64
+	ReadyReplicas *int64
+	Pods          int
+}
+
+// ResourceWave 同一 SyncWave 的受管资源
+type ResourceWave struct {
+	SyncWave  int64
+	Resources []ResourceDetail
+}
+
+// AppDetail app get 的详细信息
+type AppDetail struct {
+	App  *appv1.Application
+	Tree *appv1.ApplicationTree
+	// Waves 按 SyncWave 升序（即同步顺序）分组的受管资源
+	Waves []ResourceWave
+}
+
+// DescribeApplication 获取应用、资源树，并回读可缩容工作负载的副本数
+func (c *Client) DescribeApplication(ctx context.Context, name string) (*AppDetail, error) {
+	app, err := c.GetApplication(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	project := app.Spec.Project
+	tree, err := appCall(ctx, c, "Application.ResourceTree", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*appv1.ApplicationTree, error) {
+		return appIf.ResourceTree(ctx, &applications.ResourcesQuery{
+			Project:         &project,
+			ApplicationName: &name,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resources := append([]appv1.ResourceStatus(nil), app.Status.Resources...)
+	sort.SliceStable(resources, func(i, j int) bool { return resources[i].SyncWave < resources[j].SyncWave })
+	detail := &AppDetail{App: app, Tree: tree}
+	for i := range resources {
+		r := resources[i]
+		d := ResourceDetail{Resource: r}
+		if _, ok := canScaleWorkloads[r.Kind]; ok {
+			d.Scalable = true
+			if node := tree.FindNode(r.Group, r.Kind, r.Namespace, r.Name); node != nil {
+				d.Pods = len(workloadPods(tree, node))
+			}
+			if obj, err := c.getLiveResource(ctx, project, name, &r); err == nil {
+				d.Replicas, d.ReadyReplicas = workloadReplicas(obj)
+			}
+		}
+		if n := len(detail.Waves); n == 0 || detail.Waves[n-1].SyncWave != r.SyncWave {
+			detail.Waves = append(detail.Waves, ResourceWave{SyncWave: r.SyncWave})
+		}
+		wave := &detail.Waves[len(detail.Waves)-1]
+		wave.Resources = append(wave.Resources, d)
+	}
+	return detail, nil
+}
+
+// workloadReplicas 读取 spec.replicas 与 status.readyReplicas；无法读取的返回空（显示为未知）
+func workloadReplicas(obj *unstructured.Unstructured) (replicas, ready *int64) {
+	if v, err := specReplicas(obj); err == nil {
+		replicas = &v
+	}
+	v, found, err := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+	if err == nil {
+		if !found {
+			// 没有就绪副本时该字段被省略
+			v = 0
+		}
+		ready = &v
+	}
+	return replicas, ready
+}
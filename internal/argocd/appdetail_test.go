@@ -0,0 +1,27 @@
+package argocd
+
+import "testing"
+
+func TestWorkloadReplicas(t *testing.T) {
+	obj, err := decodeManifest(deploymentManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicas, ready := workloadReplicas(obj)
+	if replicas == nil || *replicas != 3 {
+		t.Errorf("replicas = %v, want 3", replicas)
+	}
+	if ready == nil || *ready != 2 {
+		t.Errorf("readyReplicas = %v, want 2", ready)
+	}
+
+	// 缩容到 0 后 status.readyReplicas 被省略
+	obj, err = decodeManifest(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"gate","namespace":"game"},"spec":{"replicas":0},"status":{"observedGeneration":4}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicas, ready = workloadReplicas(obj)
+	if replicas == nil || *replicas != 0 || ready == nil || *ready != 0 {
+		t.Errorf("scaled down: replicas=%v ready=%v, want 0/0", replicas, ready)
+	}
+}
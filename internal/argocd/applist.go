@@ -0,0 +1,59 @@
+package argocd
+
+import (
+	"context"
+	"strings"
+
+	applications "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+)
+
+// AppFilter app list 的过滤条件；Projects 与 Selector 交由服务端过滤，其余在客户端过滤
+type AppFilter struct {
+	Projects []string
+	// Selector 标签选择器，如 team=game,env!=dev
+	Selector string
+	// Sync 同步状态，如 Synced、OutOfSync（不区分大小写）
+	Sync string
+	// Health 健康状态，如 Healthy、Degraded（不区分大小写）
+	Health string
+	// Cluster 目标集群地址或名称
+	Cluster string
+}
+
+// Match 判断应用是否满足客户端过滤条件
+func (f AppFilter) Match(app *appv1.Application) bool {
+	if f.Sync != "" && !strings.EqualFold(string(app.Status.Sync.Status), f.Sync) {
+		return false
+	}
+	if f.Health != "" && !strings.EqualFold(string(app.Status.Health.Status), f.Health) {
+		return false
+	}
+	if f.Cluster != "" {
+		dest := app.Spec.Destination
+		if strings.TrimSuffix(dest.Server, "/") != strings.TrimSuffix(f.Cluster, "/") && dest.Name != f.Cluster {
+			return false
+		}
+	}
+	return true
+}
+
+// FindApplications 按过滤条件列出应用
+func (c *Client) FindApplications(ctx context.Context, f AppFilter) ([]appv1.Application, error) {
+	query := &applications.ApplicationQuery{Projects: f.Projects}
+	if f.Selector != "" {
+		selector := f.Selector
+		query.Selector = &selector
+	}
+	list, err := c.ListApplications(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	apps := make([]appv1.Application, 0, len(list.Items))
+	for i := range list.Items {
+		if f.Match(&list.Items[i]) {
+			apps = append(apps, list.Items[i])
+		}
+	}
+	return apps, nil
+}
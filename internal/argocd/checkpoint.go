@@ -0,0 +1,195 @@
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+)
+
+// ErrInterrupted down 被操作者中断（当前波次的 Patch 已完成，后续波次未开始）
+var ErrInterrupted = errors.New("操作已被中断")
+
+// WorkloadState 工作负载在一次 down 中的进度
+type WorkloadState string
+
+const (
+	// WorkloadPending 尚未处理
+	WorkloadPending WorkloadState = "pending"
+	// WorkloadPatched 已置 0，但未确认 Pod 全部删除
+	WorkloadPatched WorkloadState = "patched"
+	// WorkloadDone 已置 0 且 Pod 已删除
+	WorkloadDone WorkloadState = "done"
+	// WorkloadFailed 处理失败
+	WorkloadFailed WorkloadState = "failed"
+)
+
+// WorkloadProgress 单个工作负载的进度
+type WorkloadProgress struct {
+	SyncWave  int64         `json:"syncWave"`
+	Group     string        `json:"group,omitempty"`
+	Kind      string        `json:"kind"`
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	State     WorkloadState `json:"state"`
+	// Replicas 中断后回读的实际 spec.replicas，未回读时为空
+	Replicas *int64 `json:"replicas,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AtZero 判断工作负载是否已置 0（优先使用回读结果）
+func (w *WorkloadProgress) AtZero() bool {
+	if w.Replicas != nil {
+		return *w.Replicas == 0
+	}
+	return w.State == WorkloadPatched || w.State == WorkloadDone
+}
+
+func (w *WorkloadProgress) key() string {
+	return w.Group + "/" + w.Kind + "/" + w.Namespace + "/" + w.Name
+}
+
+// Checkpoint 一次 down 的进度，未完成时写入文件供 --resume 继续
+type Checkpoint struct {
+	Server    string             `json:"server,omitempty"`
+	App       string             `json:"app"`
+	Project   string             `json:"project"`
+	UpdatedAt time.Time          `json:"updatedAt"`
+	Workloads []WorkloadProgress `json:"workloads"`
+}
+
+// newCheckpoint 以计划内全部工作负载为 pending 初始化进度
+func newCheckpoint(plan *ScaleDownPlan) *Checkpoint {
+	cp := &Checkpoint{App: plan.AppName, Project: plan.Project}
+	for _, wave := range plan.Waves {
+		for _, wl := range wave.Workloads {
+			r := wl.Resource
+			cp.Workloads = append(cp.Workloads, WorkloadProgress{
+				SyncWave:  wave.SyncWave,
+				Group:     r.Group,
+				Kind:      r.Kind,
+				Namespace: r.Namespace,
+				Name:      r.Name,
+				State:     WorkloadPending,
+			})
+		}
+	}
+	return cp
+}
+
+func (cp *Checkpoint) find(r *appv1.ResourceStatus) *WorkloadProgress {
+	k := r.Group + "/" + r.Kind + "/" + r.Namespace + "/" + r.Name
+	for i := range cp.Workloads {
+		if cp.Workloads[i].key() == k {
+			return &cp.Workloads[i]
+		}
+	}
+	return nil
+}
+
+// Complete 判断是否全部工作负载都已完成
+func (cp *Checkpoint) Complete() bool {
+	for _, w := range cp.Workloads {
+		if w.State != WorkloadDone {
+			return false
+		}
+	}
+	return true
+}
+
+// MergeDone 将 prev 中已完成、但不在本次进度里的工作负载并入（--resume 时保留之前的完成记录）
+func (cp *Checkpoint) MergeDone(prev *Checkpoint) {
+	seen := map[string]bool{}
+	for _, w := range cp.Workloads {
+		seen[w.key()] = true
+	}
+	for _, w := range prev.Workloads {
+		if w.State == WorkloadDone && !seen[w.key()] {
+			cp.Workloads = append(cp.Workloads, w)
+		}
+	}
+}
+
+// ResumePlan 从计划中去掉检查点里已完成的工作负载，用于 --resume
+func ResumePlan(plan *ScaleDownPlan, cp *Checkpoint) *ScaleDownPlan {
+	done := map[string]bool{}
+	for _, w := range cp.Workloads {
+		if w.State == WorkloadDone {
+			done[w.key()] = true
+		}
+	}
+	out := *plan
+	out.Waves = nil
+	out.TotalPods = 0
+	for _, wave := range plan.Waves {
+		kept := ScaleDownWave{SyncWave: wave.SyncWave}
+		for _, wl := range wave.Workloads {
+			r := wl.Resource
+			if done[r.Group+"/"+r.Kind+"/"+r.Namespace+"/"+r.Name] {
+				continue
+			}
+			kept.Workloads = append(kept.Workloads, wl)
+			out.TotalPods += wl.Pods
+		}
+		if len(kept.Workloads) > 0 {
+			out.Waves = append(out.Waves, kept)
+		}
+	}
+	return &out
+}
+
+// RefreshReplicas 回读每个工作负载当前的 spec.replicas，读取或解析失败的保留为空（未知）
+func (c *Client) RefreshReplicas(ctx context.Context, cp *Checkpoint) {
+	for i := range cp.Workloads {
+		w := &cp.Workloads[i]
+		r := &appv1.ResourceStatus{Group: w.Group, Kind: w.Kind, Namespace: w.Namespace, Name: w.Name}
+		obj, err := c.getLiveResource(ctx, cp.Project, cp.App, r)
+		if err != nil {
+			if IsResourceNotInApp(err) {
+				zero := int64(0)
+				w.Replicas = &zero
+			}
+			continue
+		}
+		// 无法读取时保持为空，报告中显示为未知（?）
+		replicas, err := specReplicas(obj)
+		if err != nil {
+			continue
+		}
+		w.Replicas = &replicas
+	}
+}
+
+// LoadCheckpoint 读取检查点文件，不存在时返回 nil
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(b, cp); err != nil {
+		return nil, fmt.Errorf("解析检查点 %s 失败: %w", path, err)
+	}
+	return cp, nil
+}
+
+// SaveCheckpoint 写入检查点文件（0600）
+func SaveCheckpoint(path string, cp *Checkpoint) error {
+	cp.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	b, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o600)
+}
@@ -2,15 +2,27 @@ package argocd
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
-	"strings"
+	"io"
+	"net/http"
+	"os"
+	"sync"
 	"time"
 
 	apiclient "github.com/argoproj/argo-cd/v2/pkg/apiclient"
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient/account"
 	applications "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient/cluster"
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient/project"
 	"github.com/argoproj/argo-cd/v2/pkg/apiclient/session"
+	settingspkg "github.com/argoproj/argo-cd/v2/pkg/apiclient/settings"
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient/version"
 	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
-	"github.com/argoproj/gitops-engine/pkg/health"
+	"golang.org/x/net/proxy"
+	"google.golang.org/grpc/metadata"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 // ClientConfig 定义连接 Argo CD 的参数
@@ -23,31 +35,220 @@ type ClientConfig struct {
 	AuthToken   string
 	GRPCWeb     bool
 	GRPCWebRoot string
+	// ServerCAFile 校验服务端证书的 CA 文件（PEM），为空时使用系统根证书
+	ServerCAFile string
+	// ClientCertFile/ClientKeyFile mTLS 客户端证书与私钥（PEM），需同时指定
+	ClientCertFile string
+	ClientKeyFile  string
+	// Headers 每个请求附加的请求头，形如 "Name=value"（gRPC 与 grpc-web 模式均生效）
+	Headers []string
+	// ProxyURL HTTP CONNECT（http/https）或 socks5 代理地址
+	ProxyURL string
+	// PortForward 通过 kubeconfig 端口转发到 argocd-server Pod 并连接本地端口
+	PortForward bool
+	// PortForwardNamespace argocd-server 所在命名空间，为空时使用 kubeconfig 当前命名空间
+	PortForwardNamespace string
+	// KubeContext 端口转发使用的 kubeconfig 上下文，为空时使用当前上下文
+	KubeContext string
+	// PinnedFingerprint 已固定的服务端证书 SHA-256 指纹，非空时只接受该证书
+	PinnedFingerprint string
+	// TrustOnFirstUse 证书未通过校验且尚未固定时，信任并固定当前证书
+	TrustOnFirstUse bool
+	// ConfirmCert 证书未通过校验时询问操作者是否信任，为空时直接报错
+	ConfirmCert func(server string, cert *x509.Certificate, fingerprint string) bool
+	// OnPin 证书被信任后回调，用于持久化指纹
+	OnPin func(fingerprint string)
+	// RequestTimeout 单次 API 调用的超时，0 表示只受调用方 ctx 限制
+	RequestTimeout time.Duration
+	// Retry 幂等调用的重试策略，为空时使用 DefaultRetryPolicy
+	Retry *RetryPolicy
+	// ArgoCDConfig 官方 argocd CLI 配置文件路径，为空时使用 ~/.config/argocd/config
+	ArgoCDConfig string
+	// ArgoCDContext 指定使用 argocd CLI 配置中的上下文
+	ArgoCDContext string
+	// OnToken 通过用户名密码获得新 token 时回调，用于持久化会话
+	OnToken func(token string)
 }
 
-// Client 封装对各服务客户端的访问
+// Client 封装对各服务客户端的访问。
+// 各服务客户端在首次使用时建立连接并在 Close 前复用，可被多个 goroutine 并发使用。
 type Client struct {
-	conn apiclient.Client
+	retry RetryPolicy
+
+	// authMu 串行化重新认证；username/password/onToken 仅在重新认证时使用
+	authMu   sync.Mutex
+	username string
+	password string
+	onToken  func(token string)
+
+	// rpcHeaders gRPC 模式下随每个请求附加的 metadata
+	rpcHeaders metadata.MD
+	// requestTimeout 单次调用超时，调用方 ctx 可覆盖整个操作
+	requestTimeout time.Duration
+	// trustFile 固定证书的受信根证书文件（opts.CertFile），Close 时删除
+	trustFile string
+	// tunnel 使用 --proxy-url 时 apiclient 连接的本地隧道，Close 时关闭
+	tunnel *proxyTunnel
+	// oauthHTTP 使用 --proxy-url 时 SSO 使用的 HTTP 客户端，为空时使用 apiclient 提供的客户端
+	oauthHTTP *http.Client
+
+	// mu 保护 conn、opts、调用计数与下列服务客户端
+	mu        sync.Mutex
+	conn      apiclient.Client
+	opts      apiclient.ClientOptions
+	closed    bool
+	closers   []io.Closer
+	appIf     applications.ApplicationServiceClient
+	sessIf    session.SessionServiceClient
+	projIf    project.ProjectServiceClient
+	accIf     account.AccountServiceClient
+	verIf     version.VersionServiceClient
+	clusterIf cluster.ClusterServiceClient
+	setIf     settingspkg.SettingsServiceClient
+
+	// retired 重新认证后被替换的旧连接，待进行中的调用（inflight）全部结束后关闭
+	retired  []io.Closer
+	inflight int
 }
 
+// DefaultServerName 端口转发时选择 Pod 的 app.kubernetes.io/name 标签值
+const DefaultServerName = "argocd-server"
+
 // NewClient 创建 Argo CD API 客户端
 func NewClient(ctx context.Context, cfg ClientConfig) (*Client, func(), error) {
-	if cfg.ServerAddr == "" {
-		return nil, nil, fmt.Errorf("ServerAddr 不能为空")
+	configPath, configContext, err := resolveArgoCDConfig(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取 argocd CLI 配置失败: %w", err)
+	}
+	portForward := cfg.PortForward || cfg.PortForwardNamespace != ""
+	if cfg.ServerAddr == "" && configContext == "" && !portForward {
+		return nil, nil, fmt.Errorf("ServerAddr 不能为空，且没有可用的 argocd CLI 上下文")
+	}
+	if configContext != "" {
+		fmt.Fprintf(os.Stderr, "[client] using argocd CLI config %s context=%s\n", configPath, configContext)
 	}
 
-	fmt.Printf("[client] init server=%s insecure=%v tlsNoVerify=%v hasToken=%v user=%s\n",
+	fmt.Fprintf(os.Stderr, "[client] init server=%s insecure=%v tlsNoVerify=%v hasToken=%v user=%s\n",
 		cfg.ServerAddr, cfg.Insecure, cfg.TLSNoVerify, cfg.AuthToken != "", cfg.Username)
 
+	if (cfg.ClientCertFile == "") != (cfg.ClientKeyFile == "") {
+		return nil, nil, fmt.Errorf("--client-cert 与 --client-key 必须同时指定")
+	}
+
+	header, apiHeaders, err := parseHeaders(cfg.Headers)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 代理只作用于本客户端到 Argo CD 的连接（经 proxyTunnel）及 SSO 的 HTTP 客户端，不修改环境变量
+	var dialer proxy.ContextDialer
+	if cfg.ProxyURL != "" {
+		switch {
+		case portForward:
+			fmt.Fprintf(os.Stderr, "[client] --proxy-url is ignored with port-forward\n")
+		case cfg.ServerAddr == "":
+			return nil, nil, fmt.Errorf("--proxy-url 需要同时指定 --server")
+		default:
+			dialer, err = proxyDialer(cfg.ProxyURL)
+			if err != nil {
+				return nil, nil, err
+			}
+			fmt.Fprintf(os.Stderr, "[client] using proxy %s\n", redactURL(cfg.ProxyURL))
+		}
+	}
+	rpcHeaders := headerMetadata(header, cfg.GRPCWeb)
+
 	// 注意：PlainText 仅在明确需要明文 gRPC 时才应开启
 	// 这里默认走 TLS，--tls-no-verify 控制证书校验，避免把 --insecure 误当作明文连接
 	clientOpts := apiclient.ClientOptions{
-		ServerAddr:      cfg.ServerAddr,
-		Insecure:        cfg.TLSNoVerify,
-		PlainText:       cfg.Insecure,
-		AuthToken:       cfg.AuthToken,
-		GRPCWeb:         cfg.GRPCWeb,
-		GRPCWebRootPath: cfg.GRPCWebRoot,
+		ServerAddr:        cfg.ServerAddr,
+		Insecure:          cfg.TLSNoVerify,
+		PlainText:         cfg.Insecure,
+		AuthToken:         cfg.AuthToken,
+		GRPCWeb:           cfg.GRPCWeb,
+		GRPCWebRootPath:   cfg.GRPCWebRoot,
+		CertFile:          cfg.ServerCAFile,
+		ClientCertFile:    cfg.ClientCertFile,
+		ClientCertKeyFile: cfg.ClientKeyFile,
+		Headers:           apiHeaders,
+		ConfigPath:        configPath,
+		Context:           configContext,
+	}
+
+	// 端口转发时由 apiclient 经 Kubernetes API 建立隧道并连接 127.0.0.1，
+	// 隧道已由 kubeconfig 认证，apiclient 会对本地端口关闭证书校验（与 argocd --port-forward 一致）
+	if portForward {
+		clientOpts.ServerAddr = ""
+		clientOpts.PortForward = true
+		clientOpts.PortForwardNamespace = cfg.PortForwardNamespace
+		clientOpts.ServerName = DefaultServerName
+		clientOpts.KubeOverrides = &clientcmd.ConfigOverrides{CurrentContext: cfg.KubeContext}
+		fmt.Fprintf(os.Stderr, "[client] port-forward to %s in namespace=%q kubeContext=%q\n", DefaultServerName, cfg.PortForwardNamespace, cfg.KubeContext)
+	}
+
+	// 证书校验失败时不降级为不校验：固定指纹或操作者明确信任的证书作为受信根证书交给实际连接，
+	// 实际连接（携带密码与 token）同样只接受该证书或系统/CA 可校验的证书
+	var pinned *x509.Certificate
+	if !cfg.Insecure && !cfg.TLSNoVerify && cfg.ServerAddr != "" && !portForward {
+		pinned, err = verifyServerCert(ctx, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	var (
+		trustFile string
+		tunnel    *proxyTunnel
+		oauthHTTP *http.Client
+	)
+	created := false
+	defer func() {
+		if created {
+			return
+		}
+		if trustFile != "" {
+			_ = os.Remove(trustFile)
+		}
+		if tunnel != nil {
+			_ = tunnel.Close()
+		}
+	}()
+	switch {
+	case dialer != nil:
+		// apiclient 以明文连接本地隧道，TLS 由隧道按相同的受信根证书完成
+		tlsConf, err := serverTLSConfig(cfg, pinned)
+		if err != nil {
+			return nil, nil, err
+		}
+		oauthHTTP = proxyHTTPClient(dialer, tlsConf, header)
+		var tunnelTLS *tls.Config
+		if !cfg.Insecure {
+			host, _ := serverHostPort(cfg.ServerAddr)
+			tunnelTLS = tlsConf.Clone()
+			tunnelTLS.ServerName = host
+		}
+		_, addr := serverHostPort(cfg.ServerAddr)
+		tunnel, err = startProxyTunnel(dialer, addr, tunnelTLS)
+		if err != nil {
+			return nil, nil, err
+		}
+		clientOpts.ServerAddr = tunnel.Addr()
+		clientOpts.PlainText = true
+		clientOpts.Insecure = false
+		clientOpts.CertFile, clientOpts.ClientCertFile, clientOpts.ClientCertKeyFile = "", "", ""
+	case pinned != nil:
+		trustFile, err = writeTrustBundle(cfg.ServerCAFile, pinned)
+		if err != nil {
+			return nil, nil, err
+		}
+		clientOpts.CertFile = trustFile
+	}
+
+	// 已过期的 token：有用户名密码时改为重新登录，否则提示重新执行 login
+	if clientOpts.AuthToken != "" && tokenExpired(clientOpts.AuthToken) {
+		if cfg.Username == "" {
+			return nil, nil, &Error{Kind: KindUnauthenticated, Op: "auth", Err: fmt.Errorf("token 已过期，请重新执行 agt login")}
+		}
+		fmt.Fprintf(os.Stderr, "[client] token expired, re-login with username=%s\n", cfg.Username)
+		clientOpts.AuthToken = ""
 	}
 
 	client, err := apiclient.NewClient(&clientOpts)
@@ -55,128 +256,144 @@ func NewClient(ctx context.Context, cfg ClientConfig) (*Client, func(), error) {
 		return nil, nil, err
 	}
 
-	// 若无 token 且提供用户名密码，则通过 Session.Create 登录获取 token 并重建 client
-	if clientOpts.AuthToken == "" && cfg.Username != "" {
-		fmt.Printf("[client] no token, trying session login with username=%s\n", cfg.Username)
-		closer, sessIf, err := client.NewSessionClient()
+	// 若无 token（含 argocd CLI 配置中的 token）且提供用户名密码，则通过 Session.Create 登录获取 token 并重建 client
+	if client.ClientOptions().AuthToken == "" && cfg.Username != "" {
+		fmt.Fprintf(os.Stderr, "[client] no token, trying session login with username=%s\n", cfg.Username)
+		sctx, cancel := withRequestTimeout(outgoingHeaders(ctx, rpcHeaders), cfg.RequestTimeout)
+		token, err := createSession(sctx, client, cfg.Username, cfg.Password)
+		cancel()
 		if err != nil {
 			return nil, nil, err
 		}
-		defer func() { _ = closer.Close() }()
-		resp, err := sessIf.Create(ctx, &session.SessionCreateRequest{Username: cfg.Username, Password: cfg.Password})
-		if err != nil {
-			// 若为证书校验错误，自动回退为 Insecure TLS 再试一次
-			if strings.Contains(err.Error(), "x509:") || strings.Contains(err.Error(), "certificate signed by unknown authority") {
-				fmt.Printf("[client] session login failed due to TLS verify, retry with tls-no-verify\n")
-				clientOpts.Insecure = true
-				client, err = apiclient.NewClient(&clientOpts)
-				if err != nil {
-					return nil, nil, err
-				}
-				closer, sessIf, err = client.NewSessionClient()
-				if err != nil {
-					return nil, nil, err
-				}
-				defer func() { _ = closer.Close() }()
-				resp, err = sessIf.Create(ctx, &session.SessionCreateRequest{Username: cfg.Username, Password: cfg.Password})
-				if err != nil {
-					return nil, nil, err
-				}
-			} else {
-				return nil, nil, err
-			}
-		}
-		if resp != nil && resp.Token != "" {
-			fmt.Printf("[client] session login success, got token\n")
-			clientOpts.AuthToken = resp.Token
+		if token != "" {
+			fmt.Fprintf(os.Stderr, "[client] session login success, got token\n")
+			clientOpts.AuthToken = token
 			client, err = apiclient.NewClient(&clientOpts)
 			if err != nil {
 				return nil, nil, err
 			}
+			if cfg.OnToken != nil {
+				cfg.OnToken(token)
+			}
 		}
 	}
 
-	// apiclient.Client 自身不暴露 Close 方法，返回一个空 closer
-	return &Client{conn: client}, func() {}, nil
+	retry := DefaultRetryPolicy
+	if cfg.Retry != nil {
+		retry = *cfg.Retry
+	}
+	c := &Client{
+		conn:           client,
+		opts:           clientOpts,
+		rpcHeaders:     rpcHeaders,
+		requestTimeout: cfg.RequestTimeout,
+		retry:          retry,
+		username:       cfg.Username,
+		password:       cfg.Password,
+		onToken:        cfg.OnToken,
+		trustFile:      trustFile,
+		tunnel:         tunnel,
+		oauthHTTP:      oauthHTTP,
+	}
+	created = true
+	return c, func() { _ = c.Close() }, nil
 }
 
-// Version 读取服务器版本（通过 application 客户端的 List 接口探测）
-func (c *Client) Version(ctx context.Context) (string, error) {
-	// 使用 Application.List 轻探测
-	closer, appIf, err := c.conn.NewApplicationClient()
-	if err != nil {
-		return "", err
+// Close 释放已建立的全部服务连接，之后不可再使用该 Client
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var firstErr error
+	for _, cl := range append(c.retired, c.closers...) {
+		if err := cl.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
-	defer func() { _ = closer.Close() }()
-	_, err = appIf.List(ctx, &applications.ApplicationQuery{})
-	if err != nil {
-		return "", err
+	c.closers, c.retired = nil, nil
+	c.closed = true
+	if c.trustFile != "" {
+		_ = os.Remove(c.trustFile)
+		c.trustFile = ""
+	}
+	if c.tunnel != nil {
+		_ = c.tunnel.Close()
+		c.tunnel = nil
 	}
-	return "ok", nil
+	c.appIf, c.sessIf, c.projIf, c.accIf, c.verIf, c.clusterIf, c.setIf = nil, nil, nil, nil, nil, nil, nil
+	return firstErr
 }
 
-// ListApplications 返回应用列表
-func (c *Client) ListApplications(ctx context.Context, query *applications.ApplicationQuery) (*appv1.ApplicationList, error) {
-	closer, appIf, err := c.conn.NewApplicationClient()
+// serviceClient 在持锁状态下惰性创建服务客户端并缓存到 slot
+func serviceClient[T comparable](c *Client, slot *T, newFn func(apiclient.Client) (io.Closer, T, error)) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero T
+	if c.closed {
+		return zero, fmt.Errorf("argocd client 已关闭")
+	}
+	if *slot != zero {
+		return *slot, nil
+	}
+	closer, cli, err := newFn(c.conn)
 	if err != nil {
-		return nil, err
+		return zero, err
 	}
-	defer func() { _ = closer.Close() }()
-	return appIf.List(ctx, query)
+	c.closers = append(c.closers, closer)
+	*slot = cli
+	return cli, nil
 }
 
-// GetApplication 获取单个应用
-func (c *Client) GetApplication(ctx context.Context, name string) (*appv1.Application, error) {
-	closer, appIf, err := c.conn.NewApplicationClient()
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = closer.Close() }()
-	q := &applications.ApplicationQuery{}
-	q.Name = &name
-	return appIf.Get(ctx, q)
+func (c *Client) appClient() (applications.ApplicationServiceClient, error) {
+	return serviceClient(c, &c.appIf, apiclient.Client.NewApplicationClient)
 }
 
-// SyncApplication 触发同步
-func (c *Client) SyncApplication(ctx context.Context, name string, prune bool, dryRun bool, strategy *appv1.SyncStrategy) (*appv1.Application, error) {
-	closer, appIf, err := c.conn.NewApplicationClient()
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = closer.Close() }()
-	qName := name
-	dr := dryRun
-	pr := prune
-	req := &applications.ApplicationSyncRequest{
-		Name:     &qName,
-		DryRun:   &dr,
-		Prune:    &pr,
-		Strategy: strategy,
-	}
-	return appIf.Sync(ctx, req)
+func (c *Client) sessionClient() (session.SessionServiceClient, error) {
+	return serviceClient(c, &c.sessIf, apiclient.Client.NewSessionClient)
 }
 
-// WaitForHealthy 等待应用健康并同步完成
-func (c *Client) WaitForHealthy(ctx context.Context, name string, timeout time.Duration) error {
-	watchCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
-	ch := c.conn.WatchApplicationWithRetry(watchCtx, name, "")
-	deadline := time.Now().Add(timeout)
-	for ev := range ch {
-		if ev == nil {
-			continue
-		}
-		app := ev.Application
-		// app 是值类型，无法与 nil 比较；检查名称是否为空来过滤无效事件
-		if app.Name == "" {
-			continue
-		}
-		if app.Status.Sync.Status == appv1.SyncStatusCodeSynced && app.Status.Health.Status == health.HealthStatusHealthy {
-			return nil
-		}
-		if timeout > 0 && time.Now().After(deadline) {
-			return fmt.Errorf("wait healthy timeout: %s", name)
+func (c *Client) projectClient() (project.ProjectServiceClient, error) {
+	return serviceClient(c, &c.projIf, apiclient.Client.NewProjectClient)
+}
+
+func (c *Client) accountClient() (account.AccountServiceClient, error) {
+	return serviceClient(c, &c.accIf, apiclient.Client.NewAccountClient)
+}
+
+func (c *Client) versionClient() (version.VersionServiceClient, error) {
+	return serviceClient(c, &c.verIf, apiclient.Client.NewVersionClient)
+}
+
+func (c *Client) clusterClient() (cluster.ClusterServiceClient, error) {
+	return serviceClient(c, &c.clusterIf, apiclient.Client.NewClusterClient)
+}
+
+func (c *Client) settingsClient() (settingspkg.SettingsServiceClient, error) {
+	return serviceClient(c, &c.setIf, apiclient.Client.NewSettingsClient)
+}
+
+// UserInfo 返回当前 token 对应的用户信息（Username 即 token subject）
+func (c *Client) UserInfo(ctx context.Context) (*session.GetUserInfoResponse, error) {
+	return withReauth(ctx, c, "Session.GetUserInfo", func(ctx context.Context) (*session.GetUserInfoResponse, error) {
+		sessIf, err := c.sessionClient()
+		if err != nil {
+			return nil, err
 		}
-	}
-	return fmt.Errorf("watch closed before healthy: %s", name)
+		return sessIf.GetUserInfo(ctx, &session.GetUserInfoRequest{})
+	})
+}
+
+// ListApplications 返回应用列表
+func (c *Client) ListApplications(ctx context.Context, query *applications.ApplicationQuery) (*appv1.ApplicationList, error) {
+	return appCall(ctx, c, "Application.List", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*appv1.ApplicationList, error) {
+		return appIf.List(ctx, query)
+	})
+}
+
+// GetApplication 获取单个应用
+func (c *Client) GetApplication(ctx context.Context, name string) (*appv1.Application, error) {
+	q := &applications.ApplicationQuery{}
+	q.Name = &name
+	return appCall(ctx, c, "Application.Get", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*appv1.Application, error) {
+		return appIf.Get(ctx, q)
+	})
 }
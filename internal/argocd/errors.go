@@ -0,0 +1,192 @@
+package argocd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ErrorKind 错误分类，供命令映射为不同退出码
+type ErrorKind int
+
+const (
+	KindUnknown ErrorKind = iota
+	KindNotFound
+	KindPermissionDenied
+	KindUnauthenticated
+	KindUnavailable
+	KindTLS
+	KindTimeout
+	KindConflict
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindNotFound:
+		return "NotFound"
+	case KindPermissionDenied:
+		return "PermissionDenied"
+	case KindUnauthenticated:
+		return "Unauthenticated"
+	case KindUnavailable:
+		return "Unavailable"
+	case KindTLS:
+		return "TLS"
+	case KindTimeout:
+		return "Timeout"
+	case KindConflict:
+		return "Conflict"
+	default:
+		return "Unknown"
+	}
+}
+
+// Error 带分类的错误
+type Error struct {
+	Kind ErrorKind
+	Op   string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Op == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Op, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// wrapErr 为 err 附加操作名与分类；err 为 nil 时返回 nil
+func wrapErr(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: KindOf(err), Op: op, Err: err}
+}
+
+// KindOf 对任意错误分类：依次识别 *Error、本包错误类型、TLS 证书错误、context 错误、
+// Kubernetes API 错误与 gRPC 状态码
+func KindOf(err error) ErrorKind {
+	if err == nil {
+		return KindUnknown
+	}
+	var e *Error
+	if errors.As(err, &e) && e.Kind != KindUnknown {
+		return e.Kind
+	}
+	var held *LockHeldError
+	var lost *LockLostError
+	if errors.As(err, &held) || errors.As(err, &lost) {
+		return KindConflict
+	}
+	var missing *MissingPermissionsError
+	if errors.As(err, &missing) {
+		return KindPermissionDenied
+	}
+	var mismatch *CertMismatchError
+	if errors.As(err, &mismatch) || isTLSError(err) {
+		return KindTLS
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return KindTimeout
+	}
+	switch {
+	case k8serrors.IsNotFound(err):
+		return KindNotFound
+	case k8serrors.IsForbidden(err):
+		return KindPermissionDenied
+	case k8serrors.IsUnauthorized(err):
+		return KindUnauthenticated
+	case k8serrors.IsConflict(err), k8serrors.IsAlreadyExists(err):
+		return KindConflict
+	case k8serrors.IsTimeout(err), k8serrors.IsServerTimeout(err):
+		return KindTimeout
+	case k8serrors.IsServiceUnavailable(err):
+		return KindUnavailable
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return KindUnknown
+	}
+	switch st.Code() {
+	case codes.NotFound:
+		return KindNotFound
+	case codes.PermissionDenied:
+		return KindPermissionDenied
+	case codes.Unauthenticated:
+		return KindUnauthenticated
+	case codes.DeadlineExceeded:
+		return KindTimeout
+	case codes.AlreadyExists, codes.Aborted:
+		return KindConflict
+	case codes.Unavailable:
+		if statusHintOf(err) == hintTLSHandshake {
+			return KindTLS
+		}
+		return KindUnavailable
+	}
+	return KindUnknown
+}
+
+// IsNotFound 判断错误是否为资源不存在
+func IsNotFound(err error) bool {
+	return KindOf(err) == KindNotFound
+}
+
+func isTLSError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalidCert x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	var verify *tls.CertificateVerificationError
+	return errors.As(err, &unknownAuthority) ||
+		errors.As(err, &invalidCert) ||
+		errors.As(err, &hostname) ||
+		errors.As(err, &verify)
+}
+
+// IsResourceNotInApp 判断错误是否为 Argo CD 返回的“资源不属于该应用”（资源已不在应用的资源树中，
+// 通常是已被删除）。与 IsNotFound 不同，应用本身不存在或因 RBAC 不可见时不满足此条件。
+func IsResourceNotInApp(err error) bool {
+	return statusHintOf(err) == hintResourceNotInApp
+}
+
+type statusHint int
+
+const (
+	hintNone statusHint = iota
+	// hintTLSHandshake gRPC 传输层把证书校验失败转换为 Unavailable，原始 x509 错误类型丢失
+	hintTLSHandshake
+	// hintResourceNotInApp Argo CD 对不属于应用的资源返回 InvalidArgument，不带状态详情
+	hintResourceNotInApp
+)
+
+// statusHintOf 是本包唯一按错误描述分类的地方：以上两类错误没有可区分的状态码或详情，只能匹配描述。
+// 仅在状态码吻合时才检查描述，其余情况一律返回 hintNone。
+func statusHintOf(err error) statusHint {
+	st, ok := status.FromError(err)
+	if !ok {
+		return hintNone
+	}
+	msg := st.Message()
+	switch st.Code() {
+	case codes.Unavailable:
+		if strings.Contains(msg, "x509:") || strings.Contains(msg, "tls: failed to verify certificate") {
+			return hintTLSHandshake
+		}
+	case codes.InvalidArgument:
+		if strings.Contains(msg, "not found as part of application") {
+			return hintResourceNotInApp
+		}
+	}
+	return hintNone
+}
@@ -0,0 +1,40 @@
+package argocd
+
+import (
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestResourceNotInAppIsNarrow(t *testing.T) {
+	notInApp := status.Error(codes.InvalidArgument, "Deployment gate not found as part of application game")
+	appMissing := status.Error(codes.NotFound, `applications.argoproj.io "game" not found`)
+	hidden := status.Error(codes.PermissionDenied, "permission denied")
+
+	if !IsResourceNotInApp(notInApp) {
+		t.Error("resource not in app should be detected")
+	}
+	if !IsResourceNotInApp(wrapErr("Application.PatchResource", notInApp)) {
+		t.Error("wrapped resource not in app should be detected")
+	}
+	for _, err := range []error{appMissing, hidden, fmt.Errorf("not found as part of application")} {
+		if IsResourceNotInApp(err) {
+			t.Errorf("%v should not be treated as resource not in app", err)
+		}
+	}
+	if KindOf(appMissing) != KindNotFound {
+		t.Errorf("KindOf(app missing) = %s, want NotFound", KindOf(appMissing))
+	}
+}
+
+func TestKindOfTLSHandshake(t *testing.T) {
+	err := status.Error(codes.Unavailable, "connection error: desc = \"transport: authentication handshake failed: tls: failed to verify certificate: x509: certificate signed by unknown authority\"")
+	if KindOf(err) != KindTLS {
+		t.Errorf("KindOf = %s, want TLS", KindOf(err))
+	}
+	if KindOf(status.Error(codes.Unavailable, "connection refused")) != KindUnavailable {
+		t.Error("plain Unavailable should stay Unavailable")
+	}
+}
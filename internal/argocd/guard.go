@@ -0,0 +1,289 @@
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	applications "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// GuardOptions 控制 down 完成后的漂移守护
+type GuardOptions struct {
+	// Duration 守护持续时间
+	Duration time.Duration
+	// Interval 在资源树事件之外的兜底检查间隔
+	Interval time.Duration
+	// Reapply 发现漂移后重新将副本数置 0
+	Reapply bool
+}
+
+// DriftEvent 一次检测到的副本回升
+type DriftEvent struct {
+	Resource appv1.ResourceStatus
+	Replicas int64
+	Pods     int
+	// Managers 最近修改 spec.replicas 的字段管理者（来自 managedFields）
+	Managers []string
+	// Events 该资源相关的 Kubernetes 事件
+	Events []string
+	At     time.Time
+}
+
+func (d *DriftEvent) String() string {
+	return fmt.Sprintf("%s %s/%s replicas=%d pods=%d", d.Resource.Kind, d.Resource.Namespace, d.Resource.Name, d.Replicas, d.Pods)
+}
+
+// GuardScaledDown 在 down 完成后持续监视计划内的工作负载，
+// 副本数或 Pod 数回升时报告变更来源，并可选重新缩容。返回守护期间检测到的全部漂移。
+func (c *Client) GuardScaledDown(ctx context.Context, plan *ScaleDownPlan, opts GuardOptions) ([]DriftEvent, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = 30 * time.Second
+	}
+	project, appName := plan.Project, plan.AppName
+	gctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	// 订阅资源树变更；流异常结束时退化为仅按 Interval 轮询
+	trees := c.watchResourceTree(gctx, project, appName, "guard")
+
+	fmt.Fprintf(os.Stderr, "[guard] watching %d workloads of %s for %s\n", plan.WorkloadCount(), appName, opts.Duration)
+	var drifts []DriftEvent
+	// drifting 记录当前处于回升状态的 workload，避免重复报告
+	drifting := map[string]bool{}
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	var tree *appv1.ApplicationTree
+	for {
+		select {
+		case <-gctx.Done():
+			if ctx.Err() != nil {
+				return drifts, ctx.Err()
+			}
+			fmt.Fprintf(os.Stderr, "[guard] finished, %d drift(s) detected\n", len(drifts))
+			return drifts, nil
+		case t := <-trees:
+			tree = t
+		case <-ticker.C:
+			t, err := appCall(gctx, c, "Application.ResourceTree", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*appv1.ApplicationTree, error) {
+				return appIf.ResourceTree(ctx, &applications.ResourcesQuery{Project: &project, ApplicationName: &appName})
+			})
+			if err != nil {
+				if gctx.Err() != nil {
+					continue
+				}
+				return drifts, err
+			}
+			tree = t
+		}
+
+		for _, wave := range plan.Waves {
+			for _, wl := range wave.Workloads {
+				r := wl.Resource
+				key := r.Group + "/" + r.Kind + "/" + r.Namespace + "/" + r.Name
+				pods := 0
+				if node := tree.FindNode(r.Group, r.Kind, r.Namespace, r.Name); node != nil {
+					pods = len(workloadPods(tree, node))
+				}
+				obj, err := c.getLiveResource(gctx, project, appName, &r)
+				if err != nil {
+					if gctx.Err() != nil {
+						continue
+					}
+					fmt.Fprintf(os.Stderr, "[guard] get %s %s/%s failed: %v\n", r.Kind, r.Namespace, r.Name, err)
+					continue
+				}
+				replicas, err := specReplicas(obj)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "[guard] %v\n", err)
+					continue
+				}
+				if replicas == 0 && pods == 0 {
+					drifting[key] = false
+					continue
+				}
+				if drifting[key] {
+					continue
+				}
+				drifting[key] = true
+				d := DriftEvent{
+					Resource: r,
+					Replicas: replicas,
+					Pods:     pods,
+					Managers: replicasManagers(obj),
+					Events:   c.resourceEvents(gctx, project, appName, &r),
+					At:       time.Now(),
+				}
+				drifts = append(drifts, d)
+				fmt.Fprintf(os.Stderr, "[guard] DRIFT %s\n", d.String())
+				for _, m := range d.Managers {
+					fmt.Fprintf(os.Stderr, "[guard]   changed by: %s\n", m)
+				}
+				for _, e := range d.Events {
+					fmt.Fprintf(os.Stderr, "[guard]   event: %s\n", e)
+				}
+				if opts.Reapply {
+					if err := c.patchWorkloadReplicasZero(gctx, project, appName, &r); err != nil {
+						fmt.Fprintf(os.Stderr, "[guard] re-apply scale down failed for %s %s/%s: %v\n", r.Kind, r.Namespace, r.Name, err)
+					} else {
+						fmt.Fprintf(os.Stderr, "[guard] re-applied replicas=0 for %s %s/%s\n", r.Kind, r.Namespace, r.Name)
+					}
+				}
+			}
+		}
+	}
+}
+
+// watchResourceTree 订阅应用资源树变更。流异常结束后通道不再有数据（不关闭），调用方应同时定期轮询。
+func (c *Client) watchResourceTree(ctx context.Context, project, appName, tag string) <-chan *appv1.ApplicationTree {
+	trees := make(chan *appv1.ApplicationTree)
+	go func() {
+		appIf, err := c.appClient()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[%s] watch resource tree failed, fallback to polling: %v\n", tag, err)
+			return
+		}
+		stream, err := appIf.WatchResourceTree(c.rpcContext(ctx), &applications.ResourcesQuery{Project: &project, ApplicationName: &appName})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[%s] watch resource tree failed, fallback to polling: %v\n", tag, err)
+			return
+		}
+		for {
+			tree, err := stream.Recv()
+			if err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "[%s] resource tree watch closed, fallback to polling: %v\n", tag, err)
+				}
+				return
+			}
+			select {
+			case trees <- tree:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return trees
+}
+
+// getLiveResource 读取 workload 的实时清单
+func (c *Client) getLiveResource(ctx context.Context, project, appName string, r *appv1.ResourceStatus) (*unstructured.Unstructured, error) {
+	resp, err := appCall(ctx, c, "Application.GetResource", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*applications.ApplicationResourceResponse, error) {
+		return appIf.GetResource(ctx, &applications.ApplicationResourceRequest{
+			Name:         &appName,
+			Project:      &project,
+			Namespace:    &r.Namespace,
+			ResourceName: &r.Name,
+			Version:      &r.Version,
+			Group:        &r.Group,
+			Kind:         &r.Kind,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decodeManifest(resp.GetManifest())
+}
+
+// decodeManifest 解析资源清单。需使用 Unstructured 自身的解码：
+// encoding/json 会把数字解成 float64，NestedInt64 等访问器随之全部失败。
+func decodeManifest(manifest string) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON([]byte(manifest)); err != nil {
+		return nil, fmt.Errorf("解析资源清单失败: %w", err)
+	}
+	return obj, nil
+}
+
+// specReplicas 读取 spec.replicas；未设置时按 Kubernetes 默认值 1，字段类型不对时返回错误
+func specReplicas(obj *unstructured.Unstructured) (int64, error) {
+	replicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil {
+		return 0, fmt.Errorf("读取 %s %s/%s 的 spec.replicas 失败: %w", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+	}
+	if !found {
+		return 1, nil
+	}
+	return replicas, nil
+}
+
+// replicasManagers 从 managedFields 中找出拥有 spec.replicas 的管理者，按时间倒序
+func replicasManagers(obj *unstructured.Unstructured) []string {
+	type entry struct {
+		desc string
+		at   time.Time
+	}
+	var entries []entry
+	for _, mf := range obj.GetManagedFields() {
+		if mf.FieldsV1 == nil || !strings.Contains(string(mf.FieldsV1.Raw), `"f:replicas"`) {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(mf.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		spec, ok := fields["f:spec"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := spec["f:replicas"]; !ok {
+			continue
+		}
+		e := entry{desc: fmt.Sprintf("%s (%s", mf.Manager, mf.Operation)}
+		if mf.Subresource != "" {
+			e.desc += " subresource=" + mf.Subresource
+		}
+		if mf.Time != nil {
+			e.at = mf.Time.Time
+			e.desc += " at " + mf.Time.Format(time.RFC3339)
+		}
+		e.desc += ")"
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].at.After(entries[j].at) })
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e.desc)
+	}
+	return out
+}
+
+// resourceEvents 返回资源最近的事件（最多 5 条，按时间倒序）
+func (c *Client) resourceEvents(ctx context.Context, project, appName string, r *appv1.ResourceStatus) []string {
+	list, err := appCall(ctx, c, "Application.ListResourceEvents", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*corev1.EventList, error) {
+		return appIf.ListResourceEvents(ctx, &applications.ApplicationResourceEventsQuery{
+			Name:              &appName,
+			Project:           &project,
+			ResourceNamespace: &r.Namespace,
+			ResourceName:      &r.Name,
+		})
+	})
+	if err != nil {
+		return nil
+	}
+	items := list.Items
+	sort.Slice(items, func(i, j int) bool { return items[i].LastTimestamp.After(items[j].LastTimestamp.Time) })
+	var out []string
+	for i := range items {
+		if len(out) == 5 {
+			break
+		}
+		e := items[i]
+		if e.InvolvedObject.Kind != r.Kind {
+			continue
+		}
+		src := e.Source.Component
+		if src == "" {
+			src = e.ReportingController
+		}
+		out = append(out, fmt.Sprintf("%s %s %s: %s (from %s)", e.LastTimestamp.Format(time.RFC3339), e.Type, e.Reason, e.Message, src))
+	}
+	return out
+}
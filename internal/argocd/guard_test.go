@@ -0,0 +1,36 @@
+package argocd
+
+import "testing"
+
+// 与 Application.GetResource 返回的清单格式一致（JSON，数字不带小数点）
+const deploymentManifest = `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"gate","namespace":"game"},` +
+	`"spec":{"replicas":3,"selector":{"matchLabels":{"app":"gate"}}},"status":{"readyReplicas":2,"replicas":3}}`
+
+func TestSpecReplicas(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     int64
+		wantErr  bool
+	}{
+		{name: "set", manifest: deploymentManifest, want: 3},
+		{name: "zero", manifest: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"gate"},"spec":{"replicas":0}}`, want: 0},
+		{name: "unset defaults to 1", manifest: `{"apiVersion":"apps/v1","kind":"StatefulSet","metadata":{"name":"db"},"spec":{}}`, want: 1},
+		{name: "wrong type", manifest: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"gate"},"spec":{"replicas":"3"}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := decodeManifest(tt.manifest)
+			if err != nil {
+				t.Fatalf("decodeManifest: %v", err)
+			}
+			got, err := specReplicas(obj)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("specReplicas err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("specReplicas = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
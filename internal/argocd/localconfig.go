@@ -0,0 +1,77 @@
+package argocd
+
+import (
+	"fmt"
+
+	"github.com/argoproj/argo-cd/v2/util/localconfig"
+)
+
+// argoCDPortForwardServer argocd login --port-forward 在配置中记录的服务地址
+const argoCDPortForwardServer = "port-forward"
+
+// resolveArgoCDConfig 决定是否复用官方 argocd CLI 的本地配置（argocd login 写入的 ~/.config/argocd/config），
+// 返回应传给 apiclient 的配置路径与上下文名；不使用时均为空。上下文的 token 只发给它自己的服务端：
+//   - 指定了 ArgoCDContext 时使用该上下文，其服务地址与本次连接（server 或端口转发）不一致时报错
+//   - 显式提供了 token 或用户名时不使用
+//   - 端口转发时使用 argocd login --port-forward 保存的上下文（若存在）
+//   - 未指定 server 时使用 current-context
+//   - 指定了 server 时使用 server 相同的上下文（若存在）
+func resolveArgoCDConfig(cfg ClientConfig) (string, string, error) {
+	path := cfg.ArgoCDConfig
+	if path == "" {
+		p, err := localconfig.DefaultLocalConfigPath()
+		if err != nil {
+			return "", "", nil
+		}
+		path = p
+	}
+	if cfg.ArgoCDContext == "" && (cfg.AuthToken != "" || cfg.Username != "") {
+		return "", "", nil
+	}
+	local, err := localconfig.ReadLocalConfig(path)
+	if err != nil {
+		return "", "", err
+	}
+	server := cfg.ServerAddr
+	if cfg.PortForward || cfg.PortForwardNamespace != "" {
+		server = argoCDPortForwardServer
+	}
+	if cfg.ArgoCDContext != "" {
+		ref := findContextRef(local, cfg.ArgoCDContext)
+		if ref == nil {
+			return "", "", fmt.Errorf("%s 中没有上下文 %q", path, cfg.ArgoCDContext)
+		}
+		if server != "" && ref.Server != server {
+			return "", "", fmt.Errorf("上下文 %q 的服务地址为 %s，与本次连接的 %s 不一致，拒绝使用其 token", ref.Name, ref.Server, server)
+		}
+		return path, ref.Name, nil
+	}
+	if local == nil {
+		return "", "", nil
+	}
+	if server == "" {
+		if local.CurrentContext == "" {
+			return "", "", nil
+		}
+		return path, local.CurrentContext, nil
+	}
+	for _, c := range local.Contexts {
+		if c.Server == server {
+			return path, c.Name, nil
+		}
+	}
+	return "", "", nil
+}
+
+// findContextRef 按名称查找上下文，配置不存在或没有该上下文时返回 nil
+func findContextRef(local *localconfig.LocalConfig, name string) *localconfig.ContextRef {
+	if local == nil {
+		return nil
+	}
+	for i := range local.Contexts {
+		if local.Contexts[i].Name == name {
+			return &local.Contexts[i]
+		}
+	}
+	return nil
+}
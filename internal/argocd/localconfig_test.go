@@ -0,0 +1,70 @@
+package argocd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/argoproj/argo-cd/v2/util/localconfig"
+)
+
+func TestResolveArgoCDConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	err := localconfig.WriteLocalConfig(localconfig.LocalConfig{
+		CurrentContext: "prod",
+		Contexts: []localconfig.ContextRef{
+			{Name: "prod", Server: "argocd-prod.example.com", User: "prod"},
+			{Name: "port-forward", Server: "port-forward", User: "port-forward"},
+		},
+		Servers: []localconfig.Server{{Server: "argocd-prod.example.com"}, {Server: "port-forward", PlainText: true}},
+		Users:   []localconfig.User{{Name: "prod", AuthToken: "prod-token"}, {Name: "port-forward", AuthToken: "pf-token"}},
+	}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		cfg     ClientConfig
+		want    string
+		wantErr bool
+	}{
+		{"current context", ClientConfig{}, "prod", false},
+		{"matching server", ClientConfig{ServerAddr: "argocd-prod.example.com"}, "prod", false},
+		{"other server", ClientConfig{ServerAddr: "argocd-hk.example.com"}, "", false},
+		{"explicit token", ClientConfig{AuthToken: "t"}, "", false},
+		// 端口转发不能使用 current-context 的 token
+		{"port-forward", ClientConfig{PortForward: true}, "port-forward", false},
+		{"port-forward namespace", ClientConfig{PortForwardNamespace: "argocd"}, "port-forward", false},
+		{"explicit context", ClientConfig{ArgoCDContext: "prod"}, "prod", false},
+		{"explicit context matching server", ClientConfig{ArgoCDContext: "prod", ServerAddr: "argocd-prod.example.com"}, "prod", false},
+		{"explicit context other server", ClientConfig{ArgoCDContext: "prod", ServerAddr: "argocd-hk.example.com"}, "", true},
+		{"explicit context with port-forward", ClientConfig{ArgoCDContext: "prod", PortForward: true}, "", true},
+		{"unknown context", ClientConfig{ArgoCDContext: "missing"}, "", true},
+	}
+	for _, tc := range cases {
+		tc.cfg.ArgoCDConfig = path
+		_, got, err := resolveArgoCDConfig(tc.cfg)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: context = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	// 没有 argocd login --port-forward 的上下文时不复用任何 token
+	only := filepath.Join(t.TempDir(), "config")
+	err = localconfig.WriteLocalConfig(localconfig.LocalConfig{
+		CurrentContext: "prod",
+		Contexts:       []localconfig.ContextRef{{Name: "prod", Server: "argocd-prod.example.com", User: "prod"}},
+		Servers:        []localconfig.Server{{Server: "argocd-prod.example.com"}},
+		Users:          []localconfig.User{{Name: "prod", AuthToken: "prod-token"}},
+	}, only)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, got, err := resolveArgoCDConfig(ClientConfig{ArgoCDConfig: only, PortForward: true}); err != nil || got != "" {
+		t.Errorf("port-forward without a port-forward context: context = %q, error = %v", got, err)
+	}
+}
@@ -0,0 +1,343 @@
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	applications "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+)
+
+// LockAnnotation Application 上保存维护锁的注解
+const LockAnnotation = "argocd-game-tools.yafeiaa.io/lock"
+
+// Lease 维护锁（租约）内容
+type Lease struct {
+	Owner     string    `json:"owner"`
+	Operation string    `json:"operation"`
+	StartedAt time.Time `json:"startedAt"`
+	TTL       Duration  `json:"ttl"`
+	// RenewedAt 最近一次续期时间，持有者在操作期间定期续期
+	RenewedAt time.Time `json:"renewedAt,omitzero"`
+
+	// raw 为注解原文，释放时用于 JSON Patch test 校验
+	raw string
+}
+
+// Duration 以 "30m" 形式序列化的 time.Duration
+type Duration time.Duration
+
+// MarshalJSON 实现 json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// ExpiresAt 返回租约过期时间（自最近一次续期起 TTL）
+func (l *Lease) ExpiresAt() time.Time {
+	from := l.StartedAt
+	if l.RenewedAt.After(from) {
+		from = l.RenewedAt
+	}
+	return from.Add(time.Duration(l.TTL))
+}
+
+// Expired 判断租约在 now 时刻是否已过期
+func (l *Lease) Expired(now time.Time) bool {
+	return now.After(l.ExpiresAt())
+}
+
+func (l *Lease) String() string {
+	return fmt.Sprintf("owner=%s operation=%s startedAt=%s ttl=%s", l.Owner, l.Operation, l.StartedAt.Format(time.RFC3339), time.Duration(l.TTL))
+}
+
+// LockHeldError 锁已被他人持有且未过期
+type LockHeldError struct {
+	App   string
+	Lease *Lease
+}
+
+func (e *LockHeldError) Error() string {
+	return fmt.Sprintf("应用 %s 已被锁定（%s，过期于 %s），如确认需要接管请使用 --steal-lock",
+		e.App, e.Lease, e.Lease.ExpiresAt().Format(time.RFC3339))
+}
+
+// LockLostError 本次持有的锁已被他人释放或接管
+type LockLostError struct {
+	App string
+	// Current 当前的租约，锁已被释放时为空
+	Current *Lease
+}
+
+func (e *LockLostError) Error() string {
+	if e.Current == nil {
+		return fmt.Sprintf("应用 %s 的维护锁已被他人释放", e.App)
+	}
+	return fmt.Sprintf("应用 %s 的维护锁已被他人接管（%s）", e.App, e.Current)
+}
+
+// GetLock 读取应用上的维护锁；未加锁时返回 nil。
+// 注解无法解析时同时返回错误与只含原文的租约，供强制释放时按原文做 CAS
+func (c *Client) GetLock(ctx context.Context, project, appName string) (*Lease, error) {
+	app, err := c.getAppInProject(ctx, project, appName)
+	if err != nil {
+		return nil, err
+	}
+	return leaseFromApp(app)
+}
+
+// AcquireLock 在应用上写入维护锁，以锁注解本身做比较并交换（CAS）：
+// JSON Patch 先 test 注解仍为读取时的值（未加锁时 test 为 null，即注解不存在），再写入新租约。
+// 已存在未过期的锁时返回 *LockHeldError，除非 steal 为 true。
+//
+// 保证范围：Argo CD 服务端在同一份 Application 上应用 patch，test 失败时整个 patch 被拒绝，
+// 因此两个调用方基于同一旧值的写入只有一方的 test 能通过。但服务端 updateApp 遇到写冲突时会
+// 重新读取并覆盖注解，极端情况下后写入者可能覆盖先写入者；写入后的回读校验可发现大部分此类情况，
+// 仍有一个很短的窗口（先写入者回读早于后写入者覆盖）双方都认为持有锁。
+// 维护锁用于防止误操作并发，而不是严格的分布式互斥。
+func (c *Client) AcquireLock(ctx context.Context, project, appName, operation string, ttl time.Duration, steal bool) (*Lease, error) {
+	app, err := c.getAppInProject(ctx, project, appName)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := leaseFromApp(app)
+	if err != nil && !steal {
+		return nil, err
+	}
+	if existing != nil && !existing.Expired(time.Now()) {
+		if !steal {
+			return nil, &LockHeldError{App: appName, Lease: existing}
+		}
+		fmt.Fprintf(os.Stderr, "[lock] stealing lock on %s from %s\n", appName, existing)
+	}
+
+	lease := &Lease{
+		Owner:     c.lockOwner(ctx),
+		Operation: operation,
+		StartedAt: time.Now().UTC().Truncate(time.Second),
+		TTL:       Duration(ttl),
+	}
+	b, err := json.Marshal(lease)
+	if err != nil {
+		return nil, err
+	}
+	lease.raw = string(b)
+
+	if err := c.patchApplicationJSON(ctx, project, appName, lockCASOps(app.Annotations, lease.raw)); err != nil {
+		return nil, fmt.Errorf("写入维护锁失败（锁可能已被他人并发获取，请重试）: %w", err)
+	}
+
+	// 回读校验：服务端在冲突重试时可能覆盖注解，确认锁确实属于本次调用
+	app, err = c.getAppInProject(ctx, project, appName)
+	if err != nil {
+		return nil, err
+	}
+	if app.Annotations[LockAnnotation] != lease.raw {
+		current, _ := leaseFromApp(app)
+		if current == nil {
+			return nil, fmt.Errorf("写入维护锁后校验失败：注解不存在")
+		}
+		return nil, &LockHeldError{App: appName, Lease: current}
+	}
+	fmt.Fprintf(os.Stderr, "[lock] acquired %s on %s\n", lease, appName)
+	return lease, nil
+}
+
+// ReleaseLock 移除应用上的维护锁 lease（由 AcquireLock、RenewLock 或 GetLock 返回）。
+// 以注解原文做 CAS：锁已被他人接管时不移除，返回 *LockLostError。
+func (c *Client) ReleaseLock(ctx context.Context, project, appName string, lease *Lease) error {
+	app, err := c.getAppInProject(ctx, project, appName)
+	if err != nil {
+		return err
+	}
+	current, ok := app.Annotations[LockAnnotation]
+	if !ok {
+		return nil
+	}
+	if current != lease.raw {
+		return lockLost(app, appName)
+	}
+	ops := []map[string]interface{}{
+		{"op": "test", "path": lockAnnotationPath(), "value": lease.raw},
+		{"op": "remove", "path": lockAnnotationPath()},
+	}
+	if err := c.patchApplicationJSON(ctx, project, appName, ops); err != nil {
+		if lerr := c.checkLockOwned(ctx, project, appName, lease); lerr != nil {
+			return lerr
+		}
+		return fmt.Errorf("释放维护锁失败: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "[lock] released lock on %s\n", appName)
+	return nil
+}
+
+// RenewLock 续期维护锁：以注解原文做 CAS 写入新的 RenewedAt，返回续期后的租约。
+// 锁已被他人释放或接管时返回 *LockLostError。
+func (c *Client) RenewLock(ctx context.Context, project, appName string, lease *Lease) (*Lease, error) {
+	renewed := *lease
+	renewed.RenewedAt = time.Now().UTC().Truncate(time.Second)
+	b, err := json.Marshal(&renewed)
+	if err != nil {
+		return nil, err
+	}
+	renewed.raw = string(b)
+	ops := []map[string]interface{}{
+		{"op": "test", "path": lockAnnotationPath(), "value": lease.raw},
+		{"op": "replace", "path": lockAnnotationPath(), "value": renewed.raw},
+	}
+	if err := c.patchApplicationJSON(ctx, project, appName, ops); err != nil {
+		if lerr := c.checkLockOwned(ctx, project, appName, lease); lerr != nil {
+			return nil, lerr
+		}
+		return nil, fmt.Errorf("续期维护锁失败: %w", err)
+	}
+	return &renewed, nil
+}
+
+// KeepLock 在 ctx 结束前每隔 TTL/3 续期 lease，返回最后持有的租约；锁被他人释放或接管时停止续期并返回 nil。
+// 续期失败（如网络错误）时在下一周期重试。进行中的续期不随 ctx 取消，保证返回的租约与注解一致。
+func (c *Client) KeepLock(ctx context.Context, project, appName string, lease *Lease) *Lease {
+	interval := time.Duration(lease.TTL) / 3
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return lease
+		case <-ticker.C:
+		}
+		renewed, err := c.RenewLock(context.WithoutCancel(ctx), project, appName, lease)
+		var lost *LockLostError
+		switch {
+		case errors.As(err, &lost):
+			fmt.Fprintf(os.Stderr, "[lock] WARNING: lost lock on %s: %v\n", appName, err)
+			return nil
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "[lock] renew lock on %s failed, will retry: %v\n", appName, err)
+		default:
+			lease = renewed
+			fmt.Fprintf(os.Stderr, "[lock] renewed lock on %s until %s\n", appName, lease.ExpiresAt().Format(time.RFC3339))
+		}
+	}
+}
+
+// checkLockOwned 回读注解，确认仍为 lease；已变化时返回 *LockLostError
+func (c *Client) checkLockOwned(ctx context.Context, project, appName string, lease *Lease) error {
+	app, err := c.getAppInProject(ctx, project, appName)
+	if err != nil {
+		return err
+	}
+	if app.Annotations[LockAnnotation] != lease.raw {
+		return lockLost(app, appName)
+	}
+	return nil
+}
+
+func lockLost(app *appv1.Application, appName string) error {
+	current, _ := leaseFromApp(app)
+	return &LockLostError{App: appName, Current: current}
+}
+
+// lockCASOps 生成写入锁注解的 JSON Patch：先 test 注解仍为 annotations 中的当前值，再写入 value。
+// 注解不存在时 test 的值为 null（Argo CD 使用的 json-patch 实现中，test null 仅在路径不存在时通过）。
+func lockCASOps(annotations map[string]string, value string) []map[string]interface{} {
+	if annotations == nil {
+		return []map[string]interface{}{
+			{"op": "test", "path": "/metadata/annotations", "value": nil},
+			{"op": "add", "path": "/metadata/annotations", "value": map[string]string{LockAnnotation: value}},
+		}
+	}
+	current, ok := annotations[LockAnnotation]
+	if !ok {
+		return []map[string]interface{}{
+			{"op": "test", "path": lockAnnotationPath(), "value": nil},
+			{"op": "add", "path": lockAnnotationPath(), "value": value},
+		}
+	}
+	return []map[string]interface{}{
+		{"op": "test", "path": lockAnnotationPath(), "value": current},
+		{"op": "replace", "path": lockAnnotationPath(), "value": value},
+	}
+}
+
+// patchApplicationJSON 以 JSON Patch 方式修改 Application
+func (c *Client) patchApplicationJSON(ctx context.Context, project, appName string, ops []map[string]interface{}) error {
+	b, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+	patch := string(b)
+	patchType := "json"
+	_, err = appCallOnce(ctx, c, "Application.Patch", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*appv1.Application, error) {
+		return appIf.Patch(ctx, &applications.ApplicationPatchRequest{
+			Name:      &appName,
+			Project:   &project,
+			Patch:     &patch,
+			PatchType: &patchType,
+		})
+	})
+	return err
+}
+
+// getAppInProject 获取应用，project 为空时不做项目过滤
+func (c *Client) getAppInProject(ctx context.Context, project, appName string) (*appv1.Application, error) {
+	q := &applications.ApplicationQuery{Name: &appName}
+	if project != "" {
+		q.Projects = []string{project}
+	}
+	return appCall(ctx, c, "Application.Get", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*appv1.Application, error) {
+		return appIf.Get(ctx, q)
+	})
+}
+
+// lockOwner 返回锁持有者标识：Argo CD 用户名@主机名
+func (c *Client) lockOwner(ctx context.Context) string {
+	user := ""
+	if info, err := c.UserInfo(ctx); err == nil {
+		user = info.Username
+	}
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+	host, _ := os.Hostname()
+	return user + "@" + host
+}
+
+func leaseFromApp(app *appv1.Application) (*Lease, error) {
+	raw, ok := app.Annotations[LockAnnotation]
+	if !ok || raw == "" {
+		return nil, nil
+	}
+	lease := &Lease{}
+	if err := json.Unmarshal([]byte(raw), lease); err != nil {
+		return &Lease{raw: raw}, fmt.Errorf("解析维护锁注解失败: %w", err)
+	}
+	lease.raw = raw
+	return lease, nil
+}
+
+// lockAnnotationPath 返回注解的 JSON Pointer 路径（"/" 需转义为 "~1"）
+func lockAnnotationPath() string {
+	return "/metadata/annotations/" + strings.ReplaceAll(LockAnnotation, "/", "~1")
+}
@@ -0,0 +1,273 @@
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	applications "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient/version"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	jsonpatch "github.com/evanphx/json-patch"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// applyOps 用 Argo CD 服务端相同的 json-patch 实现应用补丁
+func applyOps(t *testing.T, doc string, ops []map[string]interface{}) (string, error) {
+	t.Helper()
+	b, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch, err := jsonpatch.DecodePatch(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := patch.Apply([]byte(doc))
+	return string(out), err
+}
+
+func TestLockCASOps(t *testing.T) {
+	key := `"argocd-game-tools.yafeiaa.io/lock"`
+	tests := []struct {
+		name        string
+		doc         string
+		annotations map[string]string
+		wantErr     bool
+	}{
+		{
+			name: "no annotations",
+			doc:  `{"metadata":{"name":"game"}}`,
+		},
+		{
+			name:        "no lock",
+			doc:         `{"metadata":{"name":"game","annotations":{"team":"a"}}}`,
+			annotations: map[string]string{"team": "a"},
+		},
+		{
+			name:        "expired lock unchanged",
+			doc:         `{"metadata":{"name":"game","annotations":{` + key + `:"old"}}}`,
+			annotations: map[string]string{LockAnnotation: "old"},
+		},
+		{
+			name:        "lock taken concurrently",
+			doc:         `{"metadata":{"name":"game","annotations":{"team":"a",` + key + `:"other"}}}`,
+			annotations: map[string]string{"team": "a"},
+			wantErr:     true,
+		},
+		{
+			name:        "annotations created concurrently",
+			doc:         `{"metadata":{"name":"game","annotations":{` + key + `:"other"}}}`,
+			annotations: nil,
+			wantErr:     true,
+		},
+		{
+			name:        "lock replaced concurrently",
+			doc:         `{"metadata":{"name":"game","annotations":{` + key + `:"other"}}}`,
+			annotations: map[string]string{LockAnnotation: "old"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := applyOps(t, tt.doc, lockCASOps(tt.annotations, "mine"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var obj struct {
+				Metadata struct {
+					Annotations map[string]string `json:"annotations"`
+				} `json:"metadata"`
+			}
+			if err := json.Unmarshal([]byte(out), &obj); err != nil {
+				t.Fatal(err)
+			}
+			if got := obj.Metadata.Annotations[LockAnnotation]; got != "mine" {
+				t.Errorf("lock annotation = %q, want %q", got, "mine")
+			}
+		})
+	}
+}
+
+// lockServer 内存中的单个 Application；Patch 与 Argo CD 服务端一样以 json-patch 应用，test 失败时整体拒绝
+type lockServer struct {
+	applications.UnimplementedApplicationServiceServer
+	version.UnimplementedVersionServiceServer
+
+	mu  sync.Mutex
+	app *appv1.Application
+}
+
+func (s *lockServer) Get(ctx context.Context, q *applications.ApplicationQuery) (*appv1.Application, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.app.DeepCopy(), nil
+}
+
+func (s *lockServer) Patch(ctx context.Context, req *applications.ApplicationPatchRequest) (*appv1.Application, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	patch, err := jsonpatch.DecodePatch([]byte(req.GetPatch()))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	doc, err := json.Marshal(s.app)
+	if err != nil {
+		return nil, err
+	}
+	out, err := patch.Apply(doc)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	app := &appv1.Application{}
+	if err := json.Unmarshal(out, app); err != nil {
+		return nil, err
+	}
+	s.app = app
+	return app.DeepCopy(), nil
+}
+
+func (s *lockServer) Version(ctx context.Context, _ *emptypb.Empty) (*version.VersionMessage, error) {
+	return &version.VersionMessage{Version: "v2.14.17"}, nil
+}
+
+func (s *lockServer) annotation() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.app.Annotations[LockAnnotation]
+}
+
+// startLockServer 启动 lockServer 并返回连接它的两个独立客户端（模拟两个操作者）
+func startLockServer(t *testing.T) (*lockServer, *Client, *Client) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	fake := &lockServer{app: &appv1.Application{ObjectMeta: metav1.ObjectMeta{Name: "game", Namespace: "argocd"}}}
+	applications.RegisterApplicationServiceServer(srv, fake)
+	version.RegisterVersionServiceServer(srv, fake)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	var clients []*Client
+	for i := 0; i < 2; i++ {
+		c, closer, err := NewClient(context.Background(), ClientConfig{ServerAddr: lis.Addr().String(), Insecure: true, AuthToken: "token"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(closer)
+		clients = append(clients, c)
+	}
+	return fake, clients[0], clients[1]
+}
+
+func TestLockRenewAndRelease(t *testing.T) {
+	fake, client, _ := startLockServer(t)
+	ctx := context.Background()
+
+	lease, err := client.AcquireLock(ctx, "", "game", "down", time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renewed, err := client.RenewLock(ctx, "", "game", lease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed.RenewedAt.IsZero() || renewed.ExpiresAt().Before(lease.ExpiresAt()) || fake.annotation() != renewed.raw {
+		t.Errorf("renewed = %+v, annotation %s", renewed, fake.annotation())
+	}
+
+	// 续期后旧租约不能再释放锁
+	var lost *LockLostError
+	if err := client.ReleaseLock(ctx, "", "game", lease); !errors.As(err, &lost) {
+		t.Errorf("release with a stale lease: error = %v, want *LockLostError", err)
+	}
+	if fake.annotation() != renewed.raw {
+		t.Fatal("stale lease removed the lock")
+	}
+	if err := client.ReleaseLock(ctx, "", "game", renewed); err != nil {
+		t.Fatal(err)
+	}
+	if fake.annotation() != "" {
+		t.Errorf("lock not released: %s", fake.annotation())
+	}
+}
+
+func TestLockTakenOver(t *testing.T) {
+	fake, alice, bob := startLockServer(t)
+	ctx := context.Background()
+
+	lease, err := alice.AcquireLock(ctx, "", "game", "down", time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stolen, err := bob.AcquireLock(ctx, "", "game", "sync", time.Hour, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lost *LockLostError
+	if _, err := alice.RenewLock(ctx, "", "game", lease); !errors.As(err, &lost) || lost.Current == nil || lost.Current.Operation != "sync" {
+		t.Errorf("renew after takeover: error = %v, want *LockLostError for the sync lock", err)
+	}
+	if err := alice.ReleaseLock(ctx, "", "game", lease); !errors.As(err, &lost) {
+		t.Errorf("release after takeover: error = %v, want *LockLostError", err)
+	}
+	if fake.annotation() != stolen.raw {
+		t.Errorf("lock of the new holder changed: %s", fake.annotation())
+	}
+}
+
+func TestKeepLock(t *testing.T) {
+	fake, alice, bob := startLockServer(t)
+	ctx := context.Background()
+
+	// TTL 3s 时每秒续期一次
+	lease, err := alice.AcquireLock(ctx, "", "game", "down", 3*time.Second, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keepCtx, stop := context.WithCancel(ctx)
+	kept := make(chan *Lease, 1)
+	go func() { kept <- alice.KeepLock(keepCtx, "", "game", lease) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for fake.annotation() == lease.raw {
+		if time.Now().After(deadline) {
+			t.Fatal("lock not renewed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	stop()
+	last := <-kept
+	if last == nil || last.RenewedAt.IsZero() || fake.annotation() != last.raw {
+		t.Fatalf("KeepLock returned %+v, annotation %s", last, fake.annotation())
+	}
+
+	// 被接管后停止续期并返回 nil
+	if _, err := bob.AcquireLock(ctx, "", "game", "sync", time.Hour, true); err != nil {
+		t.Fatal(err)
+	}
+	go func() { kept <- alice.KeepLock(ctx, "", "game", last) }()
+	select {
+	case got := <-kept:
+		if got != nil {
+			t.Errorf("KeepLock after takeover returned %+v, want nil", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("KeepLock kept running after the lock was taken over")
+	}
+}
@@ -0,0 +1,122 @@
+package argocd
+
+import (
+	"context"
+	"sort"
+
+	applications "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+)
+
+// PlannedWorkload 计划中的单个工作负载及其当前 Pod 数
+type PlannedWorkload struct {
+	Resource appv1.ResourceStatus
+	Pods     int
+}
+
+// ScaleDownWave 同一 SyncWave 的一组工作负载
+type ScaleDownWave struct {
+	SyncWave  int64
+	Workloads []PlannedWorkload
+}
+
+// ScaleDownPlan 描述一次 down 将要处理的内容，供执行前预览与确认
+type ScaleDownPlan struct {
+	AppName    string
+	Project    string
+	DestServer string
+	DestName   string
+	Namespaces []string
+	Waves      []ScaleDownWave
+	TotalPods  int
+}
+
+// WorkloadCount 返回计划内工作负载总数
+func (p *ScaleDownPlan) WorkloadCount() int {
+	n := 0
+	for _, w := range p.Waves {
+		n += len(w.Workloads)
+	}
+	return n
+}
+
+// Destination 返回可读的目标集群描述
+func (p *ScaleDownPlan) Destination() string {
+	if p.DestName != "" && p.DestServer != "" {
+		return p.DestName + " (" + p.DestServer + ")"
+	}
+	if p.DestName != "" {
+		return p.DestName
+	}
+	return p.DestServer
+}
+
+// PlanScaleDown 计算 down 的波次计划，并通过 ResourceTree 统计每个工作负载当前的 Pod 数
+func (c *Client) PlanScaleDown(ctx context.Context, project, appName string) (*ScaleDownPlan, error) {
+	app, workloads, err := c.getAppWorkloads(ctx, project, appName)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := appCall(ctx, c, "Application.ResourceTree", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*appv1.ApplicationTree, error) {
+		return appIf.ResourceTree(ctx, &applications.ResourcesQuery{
+			Project:         &project,
+			ApplicationName: &appName,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Project 以 Application 实际所属项目为准（--project 可能为空），保护策略按它匹配
+	plan := &ScaleDownPlan{
+		AppName:    appName,
+		Project:    app.Spec.Project,
+		DestServer: app.Spec.Destination.Server,
+		DestName:   app.Spec.Destination.Name,
+	}
+	namespaces := map[string]struct{}{}
+	for _, group := range groupBySyncWave(workloads) {
+		wave := ScaleDownWave{SyncWave: group[0].SyncWave}
+		for i := range group {
+			pods := 0
+			if parentNode := tree.FindNode(group[i].Group, group[i].Kind, group[i].Namespace, group[i].Name); parentNode != nil {
+				pods = len(workloadPods(tree, parentNode))
+			}
+			wave.Workloads = append(wave.Workloads, PlannedWorkload{Resource: group[i], Pods: pods})
+			plan.TotalPods += pods
+			namespaces[group[i].Namespace] = struct{}{}
+		}
+		plan.Waves = append(plan.Waves, wave)
+	}
+	for ns := range namespaces {
+		plan.Namespaces = append(plan.Namespaces, ns)
+	}
+	sort.Strings(plan.Namespaces)
+	return plan, nil
+}
+
+// groupBySyncWave 将已按 SyncWave 降序排列的 workloads 分组
+func groupBySyncWave(workloads []appv1.ResourceStatus) [][]appv1.ResourceStatus {
+	var groups [][]appv1.ResourceStatus
+	if len(workloads) == 0 {
+		return groups
+	}
+	currentWave := workloads[0].SyncWave
+	var buf []appv1.ResourceStatus
+	for i := range workloads {
+		w := workloads[i]
+		if w.SyncWave != currentWave {
+			// 推入上一组
+			if len(buf) > 0 {
+				groups = append(groups, buf)
+			}
+			buf = nil
+			currentWave = w.SyncWave
+		}
+		buf = append(buf, w)
+	}
+	if len(buf) > 0 {
+		groups = append(groups, buf)
+	}
+	return groups
+}
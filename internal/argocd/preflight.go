@@ -0,0 +1,205 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	applications "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/argoproj/gitops-engine/pkg/health"
+)
+
+// CheckStatus 预检项结果
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "PASS"
+	CheckWarn CheckStatus = "WARN"
+	CheckFail CheckStatus = "FAIL"
+)
+
+// CheckResult 单个预检项
+type CheckResult struct {
+	Name    string
+	Status  CheckStatus
+	Message string
+}
+
+// PreflightOptions 进行中操作的处理方式；预检只据此说明，由 SettleOperation 在确认并获取维护锁后执行
+type PreflightOptions struct {
+	// WaitOperation 大于 0 时等待进行中的同步操作结束
+	WaitOperation time.Duration
+	// TerminateOperation 为 true 时终止进行中的同步操作
+	TerminateOperation bool
+}
+
+// handles 是否会等待或终止进行中的操作
+func (o PreflightOptions) handles() bool {
+	return o.WaitOperation > 0 || o.TerminateOperation
+}
+
+// PreflightFailed 判断预检是否未通过；strict 时 WARN 也视为失败
+func PreflightFailed(results []CheckResult, strict bool) bool {
+	for _, r := range results {
+		if r.Status == CheckFail || (strict && r.Status == CheckWarn) {
+			return true
+		}
+	}
+	return false
+}
+
+// PreflightScaleDown 在 down 之前检查应用状态：进行中的操作、同步状态、缺失资源与同步窗口。
+// 预检只读，不会等待或终止进行中的操作。
+func (c *Client) PreflightScaleDown(ctx context.Context, project, appName string, opts PreflightOptions) ([]CheckResult, error) {
+	app, err := c.getAppInProject(ctx, project, appName)
+	if err != nil {
+		return nil, err
+	}
+	results := []CheckResult{
+		checkOperation(app, opts),
+		checkSyncStatus(app),
+		checkMissingResources(app),
+	}
+	windows, err := c.checkSyncWindows(ctx, project, appName)
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, windows)
+	return results, nil
+}
+
+// checkOperation 检查是否有进行中的同步操作；指定了等待或终止时降为 WARN，由 SettleOperation 处理
+func checkOperation(app *appv1.Application, opts PreflightOptions) CheckResult {
+	res := CheckResult{Name: "operation"}
+	switch {
+	case !operationRunning(app):
+		res.Status = CheckPass
+		res.Message = "无进行中的同步操作"
+	case opts.TerminateOperation:
+		res.Status = CheckWarn
+		res.Message = fmt.Sprintf("存在进行中的同步操作（phase=%s），将在确认并获取维护锁后终止", operationPhase(app))
+	case opts.WaitOperation > 0:
+		res.Status = CheckWarn
+		res.Message = fmt.Sprintf("存在进行中的同步操作（phase=%s），将在确认并获取维护锁后最多等待 %s", operationPhase(app), opts.WaitOperation)
+	default:
+		res.Status = CheckFail
+		res.Message = fmt.Sprintf("存在进行中的同步操作（phase=%s），可使用 --wait-operation 或 --terminate-operation", operationPhase(app))
+	}
+	return res
+}
+
+// SettleOperation 按 opts 终止或等待进行中的同步操作，操作仍未结束时返回 KindConflict 错误。
+// 会改变他人发起的操作，调用方须已通过策略评估、操作者确认并持有维护锁。
+func (c *Client) SettleOperation(ctx context.Context, project, appName string, opts PreflightOptions) error {
+	if !opts.handles() {
+		return nil
+	}
+	app, err := c.getAppInProject(ctx, project, appName)
+	if err != nil {
+		return err
+	}
+	if !operationRunning(app) {
+		return nil
+	}
+	if opts.TerminateOperation {
+		fmt.Fprintf(os.Stderr, "[operation] terminating running operation on %s\n", appName)
+		_, err := appCallOnce(ctx, c, "Application.TerminateOperation", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*applications.OperationTerminateResponse, error) {
+			return appIf.TerminateOperation(ctx, &applications.OperationTerminateRequest{Name: &appName, Project: &project})
+		})
+		if err != nil {
+			return fmt.Errorf("终止进行中的操作失败: %w", err)
+		}
+	}
+	wait := opts.WaitOperation
+	if wait <= 0 {
+		wait = time.Minute
+	}
+	fmt.Fprintf(os.Stderr, "[operation] waiting up to %s for running operation on %s\n", wait, appName)
+	deadline := time.Now().Add(wait)
+	for operationRunning(app) && time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+		app, err = c.getAppInProject(ctx, project, appName)
+		if err != nil {
+			return err
+		}
+	}
+	if operationRunning(app) {
+		return &Error{Kind: KindConflict, Op: "operation", Err: fmt.Errorf("进行中的同步操作在 %s 内未结束（phase=%s），已中止", wait, operationPhase(app))}
+	}
+	return nil
+}
+
+func operationRunning(app *appv1.Application) bool {
+	if app.Operation != nil {
+		return true
+	}
+	st := app.Status.OperationState
+	return st != nil && !st.Phase.Completed()
+}
+
+func checkSyncStatus(app *appv1.Application) CheckResult {
+	res := CheckResult{Name: "sync-status"}
+	if app.Status.Sync.Status == appv1.SyncStatusCodeSynced {
+		res.Status = CheckPass
+		res.Message = fmt.Sprintf("已同步到 %s", app.Status.Sync.Revision)
+		return res
+	}
+	res.Status = CheckWarn
+	res.Message = fmt.Sprintf("同步状态为 %s，down 后再 up 可能引入未预期的变更", app.Status.Sync.Status)
+	return res
+}
+
+func checkMissingResources(app *appv1.Application) CheckResult {
+	res := CheckResult{Name: "missing-resources"}
+	var missing []string
+	for _, r := range app.Status.Resources {
+		if r.Health != nil && r.Health.Status == health.HealthStatusMissing {
+			missing = append(missing, fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name))
+		}
+	}
+	if len(missing) == 0 {
+		res.Status = CheckPass
+		res.Message = "资源均存在"
+		return res
+	}
+	res.Status = CheckWarn
+	res.Message = fmt.Sprintf("%d 个资源已不存在: %s", len(missing), strings.Join(missing, ", "))
+	return res
+}
+
+// checkSyncWindows 检查项目同步窗口是否会阻止后续的 up（手动同步）
+func (c *Client) checkSyncWindows(ctx context.Context, project, appName string) (CheckResult, error) {
+	res := CheckResult{Name: "sync-windows"}
+	resp, err := appCall(ctx, c, "Application.GetApplicationSyncWindows", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*applications.ApplicationSyncWindowsResponse, error) {
+		return appIf.GetApplicationSyncWindows(ctx, &applications.ApplicationSyncWindowsQuery{Name: &appName, Project: &project})
+	})
+	if err != nil {
+		return res, err
+	}
+	if resp.CanSync != nil && !*resp.CanSync {
+		res.Status = CheckWarn
+		res.Message = "当前同步窗口禁止同步，down 后将无法立即 up"
+		return res, nil
+	}
+	var blocking []string
+	for _, w := range resp.AssignedWindows {
+		if w.GetKind() == "allow" || (w.GetKind() == "deny" && !w.GetManualSync()) {
+			blocking = append(blocking, fmt.Sprintf("%s %q for %s", w.GetKind(), w.GetSchedule(), w.GetDuration()))
+		}
+	}
+	if len(blocking) > 0 {
+		res.Status = CheckWarn
+		res.Message = "以下同步窗口可能阻止后续 up: " + strings.Join(blocking, "; ")
+		return res, nil
+	}
+	res.Status = CheckPass
+	res.Message = "无阻止同步的窗口"
+	return res, nil
+}
@@ -0,0 +1,40 @@
+package argocd
+
+import (
+	"testing"
+	"time"
+
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	synccommon "github.com/argoproj/gitops-engine/pkg/sync/common"
+)
+
+func TestCheckOperationPendingWithoutState(t *testing.T) {
+	// 刚请求的操作：Operation 已设置，控制器尚未写入 OperationState
+	app := &appv1.Application{Operation: &appv1.Operation{Sync: &appv1.SyncOperation{}}}
+	app.Name = "game"
+	if res := checkOperation(app, PreflightOptions{}); res.Status != CheckFail {
+		t.Errorf("status = %s, want %s", res.Status, CheckFail)
+	}
+}
+
+func TestCheckOperationHandledLater(t *testing.T) {
+	running := &appv1.Application{Status: appv1.ApplicationStatus{
+		OperationState: &appv1.OperationState{Phase: synccommon.OperationRunning},
+	}}
+	cases := []struct {
+		name string
+		app  *appv1.Application
+		opts PreflightOptions
+		want CheckStatus
+	}{
+		{"idle", &appv1.Application{}, PreflightOptions{TerminateOperation: true}, CheckPass},
+		{"running", running, PreflightOptions{}, CheckFail},
+		{"terminate", running, PreflightOptions{TerminateOperation: true}, CheckWarn},
+		{"wait", running, PreflightOptions{WaitOperation: time.Minute}, CheckWarn},
+	}
+	for _, tc := range cases {
+		if res := checkOperation(tc.app, tc.opts); res.Status != tc.want {
+			t.Errorf("%s: status = %s, want %s (%s)", tc.name, res.Status, tc.want, res.Message)
+		}
+	}
+}
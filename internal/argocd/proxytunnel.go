@@ -0,0 +1,138 @@
+package argocd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"time"
+
+	argohttp "github.com/argoproj/argo-cd/v2/util/http"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/proxy"
+)
+
+// proxyTunnel 在本机回环地址监听，把 apiclient 的请求经由代理转发到 Argo CD。
+// apiclient 无法指定拨号器或 HTTP Transport，使用 --proxy-url 时让 apiclient 以明文连接隧道，
+// 由隧道经代理拨号并与服务端完成 TLS（证书校验与直连时一致），代理设置不会影响进程内的其他连接。
+// 隧道按 HTTP 转发（gRPC 为明文 HTTP/2，grpc-web 为 HTTP/1.1），并把 :authority/Host 改写为服务端地址，
+// 服务端与网关看到的请求与直连时相同。
+type proxyTunnel struct {
+	ln     net.Listener
+	srv    *http.Server
+	dialer proxy.ContextDialer
+	target string
+	// tlsConf 与服务端握手的 TLS 配置，为空时明文转发（--insecure）
+	tlsConf *tls.Config
+}
+
+// startProxyTunnel 监听 127.0.0.1 的随机端口并开始转发到 target
+func startProxyTunnel(dialer proxy.ContextDialer, target string, tlsConf *tls.Config) (*proxyTunnel, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("启动代理隧道失败: %w", err)
+	}
+	t := &proxyTunnel{ln: ln, dialer: dialer, target: target, tlsConf: tlsConf}
+	scheme := "https"
+	if tlsConf == nil {
+		scheme = "http"
+	}
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.Out.URL.Scheme = scheme
+			r.Out.URL.Host = target
+			r.Out.Host = target
+		},
+		Transport: &tunnelTransport{
+			h1: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return t.dial(ctx, "http/1.1")
+				},
+				DialTLSContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return t.dial(ctx, "http/1.1")
+				},
+			},
+			h2: &http2.Transport{
+				AllowHTTP: tlsConf == nil,
+				DialTLSContext: func(ctx context.Context, _, _ string, _ *tls.Config) (net.Conn, error) {
+					return t.dial(ctx, "h2")
+				},
+			},
+		},
+		// gRPC 流式响应需要立即转发
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			fmt.Fprintf(os.Stderr, "[proxy] %s %s via %s failed: %v\n", r.Method, r.URL.Path, target, err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	t.srv = &http.Server{Handler: rp, Protocols: protocols, ReadHeaderTimeout: 30 * time.Second}
+	go func() { _ = t.srv.Serve(ln) }()
+	return t, nil
+}
+
+// Addr 隧道的本地地址，作为 apiclient 的 ServerAddr
+func (t *proxyTunnel) Addr() string {
+	return t.ln.Addr().String()
+}
+
+// Close 停止隧道并关闭经由它的全部连接
+func (t *proxyTunnel) Close() error {
+	return t.srv.Close()
+}
+
+// tunnelTransport 按 apiclient 使用的协议转发：HTTP/2（gRPC）或 HTTP/1.1（grpc-web）
+type tunnelTransport struct {
+	h1 *http.Transport
+	h2 *http2.Transport
+}
+
+func (tt *tunnelTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.ProtoMajor == 2 {
+		return tt.h2.RoundTrip(r)
+	}
+	return tt.h1.RoundTrip(r)
+}
+
+// dial 经代理连接服务端，需要时以 ALPN proto 完成 TLS 握手
+func (t *proxyTunnel) dial(ctx context.Context, proto string) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	conn, err := t.dialer.DialContext(ctx, "tcp", t.target)
+	if err != nil {
+		return nil, err
+	}
+	if t.tlsConf == nil {
+		return conn, nil
+	}
+	conf := t.tlsConf.Clone()
+	conf.NextProtos = []string{proto}
+	tlsConn := tls.Client(conn, conf)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// proxyHTTPClient SSO（OIDC 发现与换取 token）使用的 HTTP 客户端，经由同一代理拨号，
+// TLS 设置与请求头与 apiclient.HTTPClient 一致
+func proxyHTTPClient(dialer proxy.ContextDialer, tlsConf *tls.Config, header http.Header) *http.Client {
+	return &http.Client{
+		Transport: &argohttp.TransportWithHeader{
+			RoundTripper: &http.Transport{
+				DialContext:           dialer.DialContext,
+				TLSClientConfig:       tlsConf,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ExpectContinueTimeout: 1 * time.Second,
+			},
+			Header: header,
+		},
+	}
+}
@@ -0,0 +1,246 @@
+package argocd
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient/version"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// connectProxy 启动一个 HTTP CONNECT 代理，返回代理地址及已转发的 CONNECT 次数
+func connectProxy(t *testing.T) (string, *atomic.Int32) {
+	t.Helper()
+	var connects atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		connects.Add(1)
+		_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go func() {
+			_, _ = io.Copy(upstream, conn)
+			upstream.Close()
+		}()
+		_, _ = io.Copy(conn, upstream)
+		conn.Close()
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, &connects
+}
+
+func TestProxyTunnelPinnedCert(t *testing.T) {
+	loopback := net.ParseIP("127.0.0.1")
+	cert := selfSigned(t, loopback)
+	srv := tlsServer(t, cert)
+	proxyURL, connects := connectProxy(t)
+
+	dialer, err := proxyDialer(proxyURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsConf, err := serverTLSConfig(ClientConfig{}, cert.Leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsConf.ServerName = "127.0.0.1"
+	tunnel, err := startProxyTunnel(dialer, srv.Listener.Addr().String(), tlsConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tunnel.Close()
+
+	resp, err := http.Get("http://" + tunnel.Addr() + "/")
+	if err != nil {
+		t.Fatalf("request through tunnel failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if n := connects.Load(); n != 1 {
+		t.Fatalf("proxy CONNECT count = %d, want 1", n)
+	}
+}
+
+func TestProxyTunnelRejectsUntrustedCert(t *testing.T) {
+	cert := selfSigned(t, net.ParseIP("127.0.0.1"))
+	srv := tlsServer(t, cert)
+	proxyURL, _ := connectProxy(t)
+
+	dialer, err := proxyDialer(proxyURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 未固定：只信任系统根证书，自签名证书必须被拒绝
+	tlsConf, err := serverTLSConfig(ClientConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsConf.ServerName = "127.0.0.1"
+	tunnel, err := startProxyTunnel(dialer, srv.Listener.Addr().String(), tlsConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tunnel.Close()
+
+	// 握手失败由隧道以 502 返回（gRPC 客户端视为 Unavailable）
+	resp, err := http.Get("http://" + tunnel.Addr() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("status = %d with an untrusted server certificate, want 502", resp.StatusCode)
+	}
+}
+
+// TestProxyTunnelAuthority 服务端看到的 Host/:authority 应为服务地址而不是隧道地址，
+// gRPC（明文 HTTP/2 连接隧道）走 HTTP/2，grpc-web（HTTP/1.1）走 HTTP/1.1
+func TestProxyTunnelAuthority(t *testing.T) {
+	cert := selfSigned(t, net.ParseIP("127.0.0.1"))
+	type seen struct {
+		host  string
+		proto int
+	}
+	got := make(chan seen, 1)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- seen{r.Host, r.ProtoMajor}
+	}))
+	srv.EnableHTTP2 = true
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	defer srv.Close()
+	proxyURL, _ := connectProxy(t)
+
+	dialer, err := proxyDialer(proxyURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsConf, err := serverTLSConfig(ClientConfig{}, cert.Leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsConf.ServerName = "127.0.0.1"
+	target := srv.Listener.Addr().String()
+	tunnel, err := startProxyTunnel(dialer, target, tlsConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tunnel.Close()
+
+	h2c := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+	for _, tc := range []struct {
+		name   string
+		client *http.Client
+		proto  int
+	}{
+		{"grpc", h2c, 2},
+		{"grpc-web", http.DefaultClient, 1},
+	} {
+		resp, err := tc.client.Get("http://" + tunnel.Addr() + "/")
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		resp.Body.Close()
+		s := <-got
+		if s.host != target {
+			t.Errorf("%s: upstream Host = %q, want %q", tc.name, s.host, target)
+		}
+		if s.proto != tc.proto {
+			t.Errorf("%s: upstream HTTP/%d, want HTTP/%d", tc.name, s.proto, tc.proto)
+		}
+	}
+}
+
+func TestProxyDialerScheme(t *testing.T) {
+	for _, u := range []string{"http://proxy:3128", "https://proxy", "socks5://proxy:1080", "socks5h://user:pw@proxy:1080"} {
+		if _, err := proxyDialer(u); err != nil {
+			t.Errorf("proxyDialer(%q): %v", u, err)
+		}
+	}
+	for _, u := range []string{"ftp://proxy", "proxy:3128", "http://"} {
+		if _, err := proxyDialer(u); err == nil {
+			t.Errorf("proxyDialer(%q) accepted an invalid proxy", u)
+		}
+	}
+}
+
+// authorityVersionServer 记录请求的 :authority
+type authorityVersionServer struct {
+	version.UnimplementedVersionServiceServer
+	authority chan string
+}
+
+func (s *authorityVersionServer) Version(ctx context.Context, _ *emptypb.Empty) (*version.VersionMessage, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	select {
+	case s.authority <- strings.Join(md.Get(":authority"), ","):
+	default:
+	}
+	return &version.VersionMessage{Version: "v2.14.17"}, nil
+}
+
+func TestProxyTunnelGRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	fake := &authorityVersionServer{authority: make(chan string, 1)}
+	version.RegisterVersionServiceServer(srv, fake)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+	proxyURL, connects := connectProxy(t)
+
+	ctx := context.Background()
+	client, closer, err := NewClient(ctx, ClientConfig{ServerAddr: lis.Addr().String(), Insecure: true, ProxyURL: proxyURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer()
+	// 清空 apiclient 创建客户端时探测 grpc 所发的请求
+	select {
+	case <-fake.authority:
+	default:
+	}
+	v, err := client.Version(ctx)
+	if err != nil {
+		t.Fatalf("Version through tunnel: %v", err)
+	}
+	if v.Version != "v2.14.17" {
+		t.Errorf("version = %q", v.Version)
+	}
+	if got := <-fake.authority; got != lis.Addr().String() {
+		t.Errorf(":authority = %q, want %q", got, lis.Addr().String())
+	}
+	if connects.Load() == 0 {
+		t.Error("request did not go through the proxy")
+	}
+}
@@ -0,0 +1,98 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient/account"
+)
+
+// Permission 一条 Argo CD RBAC 权限：resource/action/object
+type Permission struct {
+	Resource    string
+	Action      string
+	Subresource string
+}
+
+func (p Permission) String() string {
+	return fmt.Sprintf("%s %s %s", p.Action, p.Resource, p.Subresource)
+}
+
+// MissingPermissionsError 预检发现缺失的权限
+type MissingPermissionsError struct {
+	Missing []Permission
+}
+
+func (e *MissingPermissionsError) Error() string {
+	var b strings.Builder
+	b.WriteString("权限预检失败，缺少以下权限：")
+	for _, p := range e.Missing {
+		fmt.Fprintf(&b, "\n  - %s", p)
+	}
+	return b.String()
+}
+
+// CanI 通过 Account CanI 接口检查当前 token 是否具备某项权限
+func (c *Client) CanI(ctx context.Context, p Permission) (bool, error) {
+	resp, err := withReauth(ctx, c, "Account.CanI", func(ctx context.Context) (*account.CanIResponse, error) {
+		accIf, err := c.accountClient()
+		if err != nil {
+			return nil, err
+		}
+		return accIf.CanI(ctx, &account.CanIRequest{
+			Resource:    p.Resource,
+			Action:      p.Action,
+			Subresource: p.Subresource,
+		})
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.Value == "yes", nil
+}
+
+// CheckPermissions 逐项检查权限，有缺失时返回 *MissingPermissionsError
+func (c *Client) CheckPermissions(ctx context.Context, perms []Permission) error {
+	var missing []Permission
+	for _, p := range perms {
+		ok, err := c.CanI(ctx, p)
+		if err != nil {
+			return fmt.Errorf("检查权限 %s 失败: %w", p, err)
+		}
+		fmt.Fprintf(os.Stderr, "[rbac] %s: %v\n", p, ok)
+		if !ok {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingPermissionsError{Missing: missing}
+	}
+	return nil
+}
+
+// ScaleDownPermissions 返回 down 所需的权限：get（读取资源树）、update（PatchResource），
+// noGrace 时额外需要 delete，terminate（--terminate-operation）时需要 sync
+func (c *Client) ScaleDownPermissions(ctx context.Context, project, appName string, noGrace, terminate bool) ([]Permission, error) {
+	// RBAC 对象为 <project>/<app>，未指定 project 时从应用读取
+	if project == "" {
+		app, err := c.getAppInProject(ctx, project, appName)
+		if err != nil {
+			return nil, err
+		}
+		project = app.Spec.Project
+	}
+	object := project + "/" + appName
+	perms := []Permission{
+		{Resource: "applications", Action: "get", Subresource: object},
+		{Resource: "applications", Action: "update", Subresource: object},
+	}
+	if noGrace {
+		perms = append(perms, Permission{Resource: "applications", Action: "delete", Subresource: object})
+	}
+	if terminate {
+		perms = append(perms, Permission{Resource: "applications", Action: "sync", Subresource: object})
+	}
+	return perms, nil
+}
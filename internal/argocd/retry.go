@@ -0,0 +1,84 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"time"
+)
+
+// RetryPolicy 幂等调用的指数退避重试策略
+type RetryPolicy struct {
+	// MaxAttempts 单次操作的最大尝试次数（含首次），<=1 表示不重试
+	MaxAttempts int
+	// MaxElapsed 单次操作允许的重试总时长，0 表示不限
+	MaxElapsed time.Duration
+	// InitialBackoff 首次重试前的等待时间
+	InitialBackoff time.Duration
+	// MaxBackoff 单次等待上限
+	MaxBackoff time.Duration
+	// Multiplier 每次重试等待时间的放大倍数
+	Multiplier float64
+	// Jitter 等待时间的随机抖动比例（0~1）
+	Jitter float64
+}
+
+// DefaultRetryPolicy 默认重试策略：覆盖 argocd-server 滚动重启的常见时长
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	MaxElapsed:     2 * time.Minute,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     15 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// retryable 判断错误是否为可重试的瞬时错误
+func retryable(err error) bool {
+	switch KindOf(err) {
+	case KindUnavailable, KindTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff 计算第 attempt 次重试（从 1 开始）前的等待时间
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= p.Multiplier
+		if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+			d = float64(p.MaxBackoff)
+			break
+		}
+	}
+	if p.Jitter > 0 {
+		d *= 1 + p.Jitter*(rand.Float64()*2-1)
+	}
+	return time.Duration(d)
+}
+
+// withRetry 对幂等调用 fn 按策略重试；仅重试 Unavailable/Timeout，且外层 ctx 结束时立即返回
+func withRetry[T any](ctx context.Context, p RetryPolicy, op string, fn func() (T, error)) (T, error) {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		v, err := fn()
+		if err == nil || !retryable(err) || ctx.Err() != nil {
+			return v, err
+		}
+		if attempt >= p.MaxAttempts {
+			return v, fmt.Errorf("%s: 重试 %d 次后仍失败: %w", op, attempt-1, err)
+		}
+		wait := p.backoff(attempt)
+		if p.MaxElapsed > 0 && time.Since(start)+wait > p.MaxElapsed {
+			return v, fmt.Errorf("%s: 超出重试时长预算 %s: %w", op, p.MaxElapsed, err)
+		}
+		fmt.Fprintf(os.Stderr, "[retry] %s attempt %d/%d failed: %v; retry in %s\n", op, attempt, p.MaxAttempts, err, wait.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+			return v, err
+		case <-time.After(wait):
+		}
+	}
+}
@@ -3,8 +3,9 @@ package argocd
 import (
 	"context"
 	"fmt"
+	"os"
 	"sort"
-	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/sync/errgroup"
@@ -30,19 +31,27 @@ var canScaleWorkloads = map[string]struct{}{
 	"GameStatefulSet": {},
 }
 
-// getAppWorkloads 获取并按 syncWave 逆序排序
-func (c *Client) getAppWorkloads(ctx context.Context, project, appName string) ([]appv1.ResourceStatus, error) {
-	closer, appIf, err := c.conn.NewApplicationClient()
-	if err != nil {
-		return nil, err
+// ScalableWorkloadCount 返回应用中可缩容工作负载的数量
+func ScalableWorkloadCount(app *appv1.Application) int {
+	n := 0
+	for _, res := range app.Status.Resources {
+		if _, ok := canScaleWorkloads[res.Kind]; ok {
+			n++
+		}
 	}
-	defer closer.Close()
-	app, err := appIf.Get(ctx, &applications.ApplicationQuery{
-		Name:     &appName,
-		Projects: []string{project},
+	return n
+}
+
+// getAppWorkloads 获取应用及其可缩容 workload，并按 syncWave 逆序排序
+func (c *Client) getAppWorkloads(ctx context.Context, project, appName string) (*appv1.Application, []appv1.ResourceStatus, error) {
+	app, err := appCall(ctx, c, "Application.Get", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*appv1.Application, error) {
+		return appIf.Get(ctx, &applications.ApplicationQuery{
+			Name:     &appName,
+			Projects: []string{project},
+		})
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	var workloads []appv1.ResourceStatus
 	for _, res := range app.Status.Resources {
@@ -52,48 +61,42 @@ func (c *Client) getAppWorkloads(ctx context.Context, project, appName string) (
 	}
 	sort.Slice(workloads, func(i, j int) bool { return workloads[i].SyncWave > workloads[j].SyncWave })
 	// logs: list workloads after sorting by SyncWave desc
-	fmt.Printf("Found %d scalable workloads (sorted by SyncWave desc)\n", len(workloads))
+	fmt.Fprintf(os.Stderr, "Found %d scalable workloads (sorted by SyncWave desc)\n", len(workloads))
 	for _, r := range workloads {
-		fmt.Printf("  wave=%d %s %s/%s\n", r.SyncWave, r.Kind, r.Namespace, r.Name)
+		fmt.Fprintf(os.Stderr, "  wave=%d %s %s/%s\n", r.SyncWave, r.Kind, r.Namespace, r.Name)
 	}
-	return workloads, nil
+	return app, workloads, nil
 }
 
 // patchWorkloadReplicasZero 使用 PatchResource 将副本数设为 0
 func (c *Client) patchWorkloadReplicasZero(ctx context.Context, project, appName string, r *appv1.ResourceStatus) error {
-	closer, appIf, err := c.conn.NewApplicationClient()
-	if err != nil {
-		return err
-	}
-	defer closer.Close()
 	// logs: before patch
-	fmt.Printf("Patching replicas=0: %s %s/%s\n", r.Kind, r.Namespace, r.Name)
-	_, err = appIf.PatchResource(ctx, &applications.ApplicationResourcePatchRequest{
-		Name:         &appName,
-		Project:      &project,
-		ResourceName: &r.Name,
-		Group:        &r.Group,
-		Kind:         &r.Kind,
-		Namespace:    &r.Namespace,
-		Version:      &r.Version,
-		PatchType:    &defaultPatchType,
-		Patch:        &defaultPatchJSON,
+	fmt.Fprintf(os.Stderr, "Patching replicas=0: %s %s/%s\n", r.Kind, r.Namespace, r.Name)
+	// 同一 patch 重复发送结果一致，可安全重试
+	_, err := appCall(ctx, c, "Application.PatchResource", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*applications.ApplicationResourceResponse, error) {
+		return appIf.PatchResource(ctx, &applications.ApplicationResourcePatchRequest{
+			Name:         &appName,
+			Project:      &project,
+			ResourceName: &r.Name,
+			Group:        &r.Group,
+			Kind:         &r.Kind,
+			Namespace:    &r.Namespace,
+			Version:      &r.Version,
+			PatchType:    &defaultPatchType,
+			Patch:        &defaultPatchJSON,
+		})
 	})
-	if err != nil && !strings.Contains(err.Error(), "not found as part") {
+	// 资源已不在应用中（已被删除）视为完成；应用不存在或无权限等其他错误照常返回
+	if err != nil && !IsResourceNotInApp(err) {
 		return err
 	}
 	// logs: after patch
-	fmt.Printf("Patch sent: %s %s/%s\n", r.Kind, r.Namespace, r.Name)
+	fmt.Fprintf(os.Stderr, "Patch sent: %s %s/%s\n", r.Kind, r.Namespace, r.Name)
 	return nil
 }
 
 // waitPodsDeleted 等待该 workload 关联的 Pod 全部删除
 func (c *Client) waitPodsDeleted(ctx context.Context, project, appName string, parent *appv1.ResourceStatus, noGrace bool, gracePeriod int64) error {
-	closer, appIf, err := c.conn.NewApplicationClient()
-	if err != nil {
-		return err
-	}
-	defer closer.Close()
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 	firstLoop := true
@@ -103,9 +106,11 @@ func (c *Client) waitPodsDeleted(ctx context.Context, project, appName string, p
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			tree, err := appIf.ResourceTree(ctx, &applications.ResourcesQuery{
-				Project:         &project,
-				ApplicationName: &appName,
+			tree, err := appCall(ctx, c, "Application.ResourceTree", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*appv1.ApplicationTree, error) {
+				return appIf.ResourceTree(ctx, &applications.ResourcesQuery{
+					Project:         &project,
+					ApplicationName: &appName,
+				})
 			})
 			if err != nil {
 				return err
@@ -114,51 +119,33 @@ func (c *Client) waitPodsDeleted(ctx context.Context, project, appName string, p
 			if parentNode == nil {
 				return nil
 			}
-			pods := 0
-			var podNodes []appv1.ResourceNode
-			for _, node := range tree.Nodes {
-				if node.Kind != "Pod" {
-					continue
-				}
-				// 仅统计该 workload 的子 Pod
-				if isChildNode(tree, &node, parentNode) {
-					// 排除 DaemonSet 生成的 Pod
-					skip := false
-					for _, p := range node.ParentRefs {
-						if p.Kind == "DaemonSet" {
-							skip = true
-							break
-						}
-					}
-					if !skip {
-						pods++
-						podNodes = append(podNodes, node)
-					}
-				}
-			}
+			podNodes := workloadPods(tree, parentNode)
+			pods := len(podNodes)
 			if pods == 0 {
-				fmt.Printf("All pods deleted for %s %s/%s\n", parent.Kind, parent.Namespace, parent.Name)
+				fmt.Fprintf(os.Stderr, "All pods deleted for %s %s/%s\n", parent.Kind, parent.Namespace, parent.Name)
 				return nil
 			}
-			fmt.Printf("Remaining pods=%d for %s %s/%s\n", pods, parent.Kind, parent.Namespace, parent.Name)
+			fmt.Fprintf(os.Stderr, "Remaining pods=%d for %s %s/%s\n", pods, parent.Kind, parent.Namespace, parent.Name)
 
 			// 强制删除（只在第一次循环执行一次）
 			if noGrace && firstLoop {
 				// 获取 app 以获取 kube-apiserver 地址
-				app, err := appIf.Get(ctx, &applications.ApplicationQuery{Name: &appName, Projects: []string{project}})
+				app, err := appCall(ctx, c, "Application.Get", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*appv1.Application, error) {
+					return appIf.Get(ctx, &applications.ApplicationQuery{Name: &appName, Projects: []string{project}})
+				})
 				if err != nil {
 					return err
 				}
 				if k8sCli == nil {
-					token := c.conn.ClientOptions().AuthToken
-					cli, err := newK8sClient(app.Spec.Destination.Server, token)
+					token := c.AuthToken()
+					cli, err := newK8sClient(app.Spec.Destination.Server, token, c.requestTimeout)
 					if err != nil {
 						return err
 					}
 					k8sCli = cli
 				}
 				gp := gracePeriod
-				fmt.Printf("Force deleting %d pods (grace=%d) for %s %s/%s\n", len(podNodes), gp, parent.Kind, parent.Namespace, parent.Name)
+				fmt.Fprintf(os.Stderr, "Force deleting %d pods (grace=%d) for %s %s/%s\n", len(podNodes), gp, parent.Kind, parent.Namespace, parent.Name)
 				for _, p := range podNodes {
 					// 忽略删除错误，除非不是 NotFound
 					delErr := k8sCli.CoreV1().Pods(p.Namespace).Delete(ctx, p.Name, metav1.DeleteOptions{GracePeriodSeconds: &gp})
@@ -172,6 +159,32 @@ func (c *Client) waitPodsDeleted(ctx context.Context, project, appName string, p
 	}
 }
 
+// workloadPods 返回 parent 下属的 Pod 节点（排除 DaemonSet 生成的 Pod）
+func workloadPods(tree *appv1.ApplicationTree, parentNode *appv1.ResourceNode) []appv1.ResourceNode {
+	var podNodes []appv1.ResourceNode
+	for _, node := range tree.Nodes {
+		if node.Kind != "Pod" {
+			continue
+		}
+		// 仅统计该 workload 的子 Pod
+		if !isChildNode(tree, &node, parentNode) {
+			continue
+		}
+		// 排除 DaemonSet 生成的 Pod
+		skip := false
+		for _, p := range node.ParentRefs {
+			if p.Kind == "DaemonSet" {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			podNodes = append(podNodes, node)
+		}
+	}
+	return podNodes
+}
+
 // isChildNode 判断 node 是否为 parent 的子孙节点
 func isChildNode(tree *appv1.ApplicationTree, node, parent *appv1.ResourceNode) bool {
 	if node == nil || parent == nil {
@@ -192,73 +205,116 @@ func isChildNode(tree *appv1.ApplicationTree, node, parent *appv1.ResourceNode)
 	return false
 }
 
-// ScaleDownBySyncWave 将 app 内可缩容的 workload 按 syncWave 逆序置 0：
-// - 同一 SyncWave 内并行 Patch 并等待其 Pod 删除
-// - 不同 SyncWave 之间保持顺序，上一波完成后再进行下一波
+// ScaleDownOptions down 的执行参数
+type ScaleDownOptions struct {
+	NoGrace     bool
+	GracePeriod int64
+	// Stop 关闭后不再开始新的波次；当前波次的 Patch 会完成，Pod 删除的等待被放弃
+	Stop <-chan struct{}
+}
+
+// ScaleDownBySyncWave 计算计划并立即执行，等价于 PlanScaleDown + ExecuteScaleDown
 func (c *Client) ScaleDownBySyncWave(ctx context.Context, project, appName string, noGrace bool, gracePeriod int64) error {
-	fmt.Printf("Start scale down app=%s project=%s\n", appName, project)
-	workloads, err := c.getAppWorkloads(ctx, project, appName)
+	plan, err := c.PlanScaleDown(ctx, project, appName)
 	if err != nil {
 		return err
 	}
-	// 将 workloads（已按 SyncWave 降序）分组
-	var groups [][]appv1.ResourceStatus
-	if len(workloads) > 0 {
-		currentWave := workloads[0].SyncWave
-		var buf []appv1.ResourceStatus
-		for i := range workloads {
-			w := workloads[i]
-			if w.SyncWave != currentWave {
-				// 推入上一组
-				if len(buf) > 0 {
-					groups = append(groups, buf)
-				}
-				buf = nil
-				currentWave = w.SyncWave
+	_, err = c.ExecuteScaleDown(ctx, plan, ScaleDownOptions{NoGrace: noGrace, GracePeriod: gracePeriod})
+	return err
+}
+
+// ExecuteScaleDown 将计划内的 workload 按 syncWave 逆序置 0：
+// - 同一 SyncWave 内并行 Patch 并等待其 Pod 删除
+// - 不同 SyncWave 之间保持顺序，上一波完成后再进行下一波
+//
+// 返回的 Checkpoint 记录每个工作负载的进度，出错或被 opts.Stop 中断（返回 ErrInterrupted）时同样返回。
+func (c *Client) ExecuteScaleDown(ctx context.Context, plan *ScaleDownPlan, opts ScaleDownOptions) (*Checkpoint, error) {
+	project, appName := plan.Project, plan.AppName
+	fmt.Fprintf(os.Stderr, "Start scale down app=%s project=%s\n", appName, project)
+
+	cp := newCheckpoint(plan)
+	var mu sync.Mutex
+	setState := func(r *appv1.ResourceStatus, state WorkloadState, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if w := cp.find(r); w != nil {
+			w.State = state
+			if err != nil {
+				w.Error = err.Error()
 			}
-			buf = append(buf, w)
-		}
-		if len(buf) > 0 {
-			groups = append(groups, buf)
 		}
 	}
 
 	// 按波次执行：同波并行，波次之间串行
-	for _, group := range groups {
-		if len(group) == 0 {
+	for _, wave := range plan.Waves {
+		if len(wave.Workloads) == 0 {
 			continue
 		}
-		wave := group[0].SyncWave
-		fmt.Printf("Processing wave=%d with %d workloads in parallel\n", wave, len(group))
+		if stopped(opts.Stop) {
+			fmt.Fprintf(os.Stderr, "Interrupted, wave=%d and later waves not started\n", wave.SyncWave)
+			return cp, ErrInterrupted
+		}
+		fmt.Fprintf(os.Stderr, "Processing wave=%d with %d workloads in parallel\n", wave.SyncWave, len(wave.Workloads))
 		g, gctx := errgroup.WithContext(ctx)
-		for i := range group {
-			w := group[i]
-			wCopy := w
+		// 中断时放弃等待 Pod 删除，但已开始的 Patch 仍使用 gctx 完成
+		waitCtx, cancelWait := context.WithCancel(gctx)
+		go func() {
+			select {
+			case <-opts.Stop:
+				cancelWait()
+			case <-waitCtx.Done():
+			}
+		}()
+		for i := range wave.Workloads {
+			wCopy := wave.Workloads[i].Resource
 			g.Go(func() error {
 				if err := c.patchWorkloadReplicasZero(gctx, project, appName, &wCopy); err != nil {
+					setState(&wCopy, WorkloadFailed, err)
 					return fmt.Errorf("patch %s/%s/%s replicas=0: %w", wCopy.Kind, wCopy.Namespace, wCopy.Name, err)
 				}
-				if err := c.waitPodsDeleted(gctx, project, appName, &wCopy, noGrace, gracePeriod); err != nil {
+				setState(&wCopy, WorkloadPatched, nil)
+				if err := c.waitPodsDeleted(waitCtx, project, appName, &wCopy, opts.NoGrace, opts.GracePeriod); err != nil {
+					if stopped(opts.Stop) && gctx.Err() == nil {
+						return nil
+					}
 					return fmt.Errorf("wait pods deleted for %s/%s/%s: %w", wCopy.Kind, wCopy.Namespace, wCopy.Name, err)
 				}
-				fmt.Printf("Scaled down: %s %s/%s\n", wCopy.Kind, wCopy.Namespace, wCopy.Name)
+				setState(&wCopy, WorkloadDone, nil)
+				fmt.Fprintf(os.Stderr, "Scaled down: %s %s/%s\n", wCopy.Kind, wCopy.Namespace, wCopy.Name)
 				return nil
 			})
 		}
-		if err := g.Wait(); err != nil {
-			return err
+		err := g.Wait()
+		cancelWait()
+		if err != nil {
+			return cp, err
+		}
+		if stopped(opts.Stop) {
+			fmt.Fprintf(os.Stderr, "Wave %d patched, interrupted before next wave\n", wave.SyncWave)
+			return cp, ErrInterrupted
 		}
-		fmt.Printf("Wave %d completed\n", wave)
+		fmt.Fprintf(os.Stderr, "Wave %d completed\n", wave.SyncWave)
+	}
+	fmt.Fprintln(os.Stderr, "Scale down finished")
+	return cp, nil
+}
+
+// stopped 判断 stop 是否已关闭；nil 表示不可中断
+func stopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
 	}
-	fmt.Println("Scale down finished")
-	return nil
 }
 
-func newK8sClient(server, token string) (*kubernetes.Clientset, error) {
+func newK8sClient(server, token string, timeout time.Duration) (*kubernetes.Clientset, error) {
 	cfg := &rest.Config{
 		Host:            server,
 		BearerToken:     token,
 		TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+		Timeout:         timeout,
 	}
 	return kubernetes.NewForConfig(cfg)
 }
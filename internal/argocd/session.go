@@ -0,0 +1,169 @@
+package argocd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	apiclient "github.com/argoproj/argo-cd/v2/pkg/apiclient"
+	applications "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient/session"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// tokenExpirySkew 距离过期不足该时长的 token 视为已过期，避免请求途中失效
+const tokenExpirySkew = 30 * time.Second
+
+// tokenExpired 判断 JWT token 是否已过期；非 JWT 或无 exp 的 token 视为未过期
+func tokenExpired(token string) bool {
+	claims := jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil {
+		return false
+	}
+	return claims.ExpiresAt != nil && time.Now().Add(tokenExpirySkew).After(claims.ExpiresAt.Time)
+}
+
+// createSession 使用用户名密码通过 Session.Create 获取 token
+func createSession(ctx context.Context, conn apiclient.Client, username, password string) (string, error) {
+	closer, sessIf, err := conn.NewSessionClient()
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = closer.Close() }()
+	resp, err := sessIf.Create(ctx, &session.SessionCreateRequest{Username: username, Password: password})
+	if err != nil {
+		return "", wrapErr("session login", err)
+	}
+	return resp.Token, nil
+}
+
+// AuthToken 返回当前使用的 token
+func (c *Client) AuthToken() string {
+	return c.apiConn().ClientOptions().AuthToken
+}
+
+// apiConn 返回当前底层连接（重新认证后会被替换）
+func (c *Client) apiConn() apiclient.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn
+}
+
+// reauthenticate 在 token 失效时用用户名密码重新登录，并以新 token 重建全部服务连接。
+// stale 为失败调用所使用的 token；若已被其他调用刷新则直接返回。
+func (c *Client) reauthenticate(ctx context.Context, stale string) error {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	if c.AuthToken() != stale {
+		return nil
+	}
+	if c.username == "" {
+		return errors.New("未提供用户名密码")
+	}
+	fmt.Fprintf(os.Stderr, "[client] token rejected, re-login with username=%s\n", c.username)
+	sctx, cancel := withRequestTimeout(c.rpcContext(ctx), c.requestTimeout)
+	defer cancel()
+	token, err := createSession(sctx, c.apiConn(), c.username, c.password)
+	if err != nil {
+		return err
+	}
+	opts := c.opts
+	opts.AuthToken = token
+	conn, err := apiclient.NewClient(&opts)
+	if err != nil {
+		return err
+	}
+
+	// 其他 goroutine 可能正在旧连接上调用，旧连接退役后由最后一个进行中的调用关闭
+	c.mu.Lock()
+	c.retired = append(c.retired, c.closers...)
+	c.closers = nil
+	if c.inflight == 0 {
+		c.closeRetired()
+	}
+	c.appIf, c.sessIf, c.projIf, c.accIf, c.verIf, c.clusterIf, c.setIf = nil, nil, nil, nil, nil, nil, nil
+	c.conn = conn
+	c.opts = opts
+	c.mu.Unlock()
+
+	if c.onToken != nil {
+		c.onToken(token)
+	}
+	return nil
+}
+
+// withReauth 执行 fn；返回 Unauthenticated 时尝试重新认证并再执行一次。
+// 被拒绝的请求不会在服务端生效，因此对非幂等调用重发也是安全的。
+func withReauth[T any](ctx context.Context, c *Client, op string, fn func(context.Context) (T, error)) (T, error) {
+	used := c.AuthToken()
+	v, err := callOnce(ctx, c, fn)
+	if KindOf(err) != KindUnauthenticated {
+		return v, err
+	}
+	if rerr := c.reauthenticate(ctx, used); rerr != nil {
+		return v, &Error{Kind: KindUnauthenticated, Op: op, Err: fmt.Errorf("认证已失效且无法自动重新登录（%v），请执行 agt login: %w", rerr, err)}
+	}
+	return callOnce(ctx, c, fn)
+}
+
+// callOnce 以单次调用超时与附加请求头执行 fn；调用期间计入 inflight，所用连接不会被重新认证关闭
+func callOnce[T any](ctx context.Context, c *Client, fn func(context.Context) (T, error)) (T, error) {
+	c.beginCall()
+	defer c.endCall()
+	cctx, cancel := withRequestTimeout(c.rpcContext(ctx), c.requestTimeout)
+	defer cancel()
+	return fn(cctx)
+}
+
+func (c *Client) beginCall() {
+	c.mu.Lock()
+	c.inflight++
+	c.mu.Unlock()
+}
+
+// endCall 结束一次调用；没有进行中的调用时关闭已退役的连接
+func (c *Client) endCall() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inflight--
+	if c.inflight == 0 {
+		c.closeRetired()
+	}
+}
+
+// closeRetired 关闭已退役的连接，调用方需持有 mu
+func (c *Client) closeRetired() {
+	for _, cl := range c.retired {
+		_ = cl.Close()
+	}
+	c.retired = nil
+}
+
+// withRequestTimeout 为单次调用设置超时；d 为 0 时只受 ctx 限制
+func withRequestTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// appCall 对 Application 服务的幂等调用：瞬时错误按策略重试，token 失效时重新认证
+func appCall[T any](ctx context.Context, c *Client, op string, fn func(context.Context, applications.ApplicationServiceClient) (T, error)) (T, error) {
+	return withRetry(ctx, c.retry, op, func() (T, error) {
+		return appCallOnce(ctx, c, op, fn)
+	})
+}
+
+// appCallOnce 对 Application 服务的非幂等调用：不重试瞬时错误，仅处理 token 失效
+func appCallOnce[T any](ctx context.Context, c *Client, op string, fn func(context.Context, applications.ApplicationServiceClient) (T, error)) (T, error) {
+	return withReauth(ctx, c, op, func(ctx context.Context) (T, error) {
+		appIf, err := c.appClient()
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		return fn(ctx, appIf)
+	})
+}
@@ -0,0 +1,126 @@
+package argocd
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	apiclient "github.com/argoproj/argo-cd/v2/pkg/apiclient"
+	applications "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient/session"
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient/version"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// reauthServer 只接受 Session.Create 签发的 token；名为 slow 的应用接受任意 token，
+// 并阻塞到 release 关闭，用于模拟重新认证时仍在旧连接上进行的调用
+type reauthServer struct {
+	applications.UnimplementedApplicationServiceServer
+	version.UnimplementedVersionServiceServer
+
+	slowStarted chan struct{}
+	release     chan struct{}
+	logins      atomic.Int32
+}
+
+func (s *reauthServer) Get(ctx context.Context, q *applications.ApplicationQuery) (*appv1.Application, error) {
+	if q.GetName() == "slow" {
+		close(s.slowStarted)
+		<-s.release
+	} else if md, _ := metadata.FromIncomingContext(ctx); len(md.Get(apiclient.MetaDataTokenKey)) == 0 || md.Get(apiclient.MetaDataTokenKey)[0] != "fresh" {
+		return nil, status.Error(codes.Unauthenticated, "invalid session")
+	}
+	return &appv1.Application{ObjectMeta: metav1.ObjectMeta{Name: q.GetName()}}, nil
+}
+
+// reauthSessions reauthServer 的 Session 服务（与 Application 服务有同名方法，需单独注册）
+type reauthSessions struct {
+	session.UnimplementedSessionServiceServer
+	logins *atomic.Int32
+}
+
+func (s *reauthSessions) Create(ctx context.Context, req *session.SessionCreateRequest) (*session.SessionResponse, error) {
+	s.logins.Add(1)
+	return &session.SessionResponse{Token: "fresh"}, nil
+}
+
+func (s *reauthServer) Version(ctx context.Context, _ *emptypb.Empty) (*version.VersionMessage, error) {
+	return &version.VersionMessage{Version: "v2.14.17"}, nil
+}
+
+func TestReauthenticateKeepsInflightCalls(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	fake := &reauthServer{slowStarted: make(chan struct{}), release: make(chan struct{})}
+	applications.RegisterApplicationServiceServer(srv, fake)
+	session.RegisterSessionServiceServer(srv, &reauthSessions{logins: &fake.logins})
+	version.RegisterVersionServiceServer(srv, fake)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	ctx := context.Background()
+	client, closer, err := NewClient(ctx, ClientConfig{
+		ServerAddr: lis.Addr().String(),
+		Insecure:   true,
+		AuthToken:  "stale",
+		Username:   "admin",
+		Password:   "password",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer()
+
+	slowErr := make(chan error, 1)
+	go func() {
+		_, err := client.GetApplication(ctx, "slow")
+		slowErr <- err
+	}()
+	<-fake.slowStarted
+
+	// 并发调用同时遇到 token 失效：只重新登录一次，且替换连接不影响进行中的 slow 调用
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetApplication(ctx, "game")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("call across re-auth: %v", err)
+		}
+	}
+	close(fake.release)
+	if err := <-slowErr; err != nil {
+		t.Errorf("in-flight call on the replaced connection: %v", err)
+	}
+	if n := fake.logins.Load(); n != 1 {
+		t.Errorf("logins = %d, want 1", n)
+	}
+	if client.AuthToken() != "fresh" {
+		t.Errorf("token = %q, want fresh", client.AuthToken())
+	}
+	client.mu.Lock()
+	retired := len(client.retired)
+	client.mu.Unlock()
+	if retired != 0 {
+		t.Errorf("%d retired connection(s) left open after all calls finished", retired)
+	}
+}
@@ -0,0 +1,45 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	settingspkg "github.com/argoproj/argo-cd/v2/pkg/apiclient/settings"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// SSOConfig 通过 Settings API 读取 Argo CD 的 OIDC 配置（Dex 或外部 OIDC），
+// 返回 CLI 客户端的 oauth2 配置及带有当前 TLS 设置的 HTTP 客户端（用于换取 token）
+func (c *Client) SSOConfig(ctx context.Context) (*oauth2.Config, *http.Client, error) {
+	set, err := withRetry(ctx, c.retry, "Settings.Get", func() (*settingspkg.Settings, error) {
+		return withReauth(ctx, c, "Settings.Get", func(ctx context.Context) (*settingspkg.Settings, error) {
+			setIf, err := c.settingsClient()
+			if err != nil {
+				return nil, err
+			}
+			return setIf.Get(ctx, &settingspkg.SettingsQuery{})
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if set.DexConfig == nil && set.OIDCConfig == nil {
+		return nil, nil, fmt.Errorf("Argo CD 服务器未配置 SSO（dex.config / oidc.config）")
+	}
+	conn := c.apiConn()
+	httpClient := c.oauthHTTP
+	if httpClient == nil {
+		httpClient, err = conn.HTTPClient()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	// OIDC 发现同样使用该客户端，以沿用代理与受信证书
+	oauth2conf, _, err := conn.OIDCConfig(oidc.ClientContext(ctx, httpClient), set)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取 OIDC 配置失败: %w", err)
+	}
+	return oauth2conf, httpClient, nil
+}
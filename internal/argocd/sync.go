@@ -0,0 +1,114 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	applications "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+)
+
+// SyncOptions app sync 的参数，对应 ApplicationSyncRequest
+type SyncOptions struct {
+	Prune  bool
+	DryRun bool
+	// Revision 同步到的版本（分支、标签或提交），为空时使用应用的 targetRevision
+	Revision string
+	// Resources 只同步这些资源，为空时同步全部
+	Resources []appv1.SyncOperationResource
+	// Strategy apply 或 hook，为空时使用服务端默认（hook）
+	Strategy string
+	Force    bool
+	// Options 附加的同步选项，如 Validate=false、CreateNamespace=true
+	Options            []string
+	ServerSide         bool
+	ApplyOutOfSyncOnly bool
+	// RetryLimit 同步失败后的重试次数，0 表示不重试
+	RetryLimit   int64
+	RetryBackoff time.Duration
+}
+
+// ParseSyncResource 解析 GROUP:KIND:NAME 或 GROUP:KIND:NAMESPACE/NAME 形式的资源选择，核心组的 GROUP 为空
+func ParseSyncResource(s string) (appv1.SyncOperationResource, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return appv1.SyncOperationResource{}, fmt.Errorf("资源 %q 格式错误，应为 GROUP:KIND:NAME 或 GROUP:KIND:NAMESPACE/NAME（核心组 GROUP 留空）", s)
+	}
+	r := appv1.SyncOperationResource{Group: parts[0], Kind: parts[1], Name: parts[2]}
+	if ns, name, ok := strings.Cut(parts[2], "/"); ok {
+		if name == "" {
+			return appv1.SyncOperationResource{}, fmt.Errorf("资源 %q 缺少名称", s)
+		}
+		r.Namespace, r.Name = ns, name
+	}
+	return r, nil
+}
+
+// syncRequest 将 SyncOptions 转换为 ApplicationSyncRequest
+func (o SyncOptions) syncRequest(name string) (*applications.ApplicationSyncRequest, error) {
+	req := &applications.ApplicationSyncRequest{
+		Name:   &name,
+		DryRun: &o.DryRun,
+		Prune:  &o.Prune,
+	}
+	if o.Revision != "" {
+		req.Revision = &o.Revision
+	}
+	for i := range o.Resources {
+		req.Resources = append(req.Resources, &o.Resources[i])
+	}
+	switch o.Strategy {
+	case "apply":
+		req.Strategy = &appv1.SyncStrategy{Apply: &appv1.SyncStrategyApply{Force: o.Force}}
+	case "hook":
+		req.Strategy = &appv1.SyncStrategy{Hook: &appv1.SyncStrategyHook{SyncStrategyApply: appv1.SyncStrategyApply{Force: o.Force}}}
+	case "":
+		// --force 需要随策略下发，未指定策略时与服务端默认一致使用 hook
+		if o.Force {
+			req.Strategy = &appv1.SyncStrategy{Hook: &appv1.SyncStrategyHook{SyncStrategyApply: appv1.SyncStrategyApply{Force: true}}}
+		}
+	default:
+		return nil, fmt.Errorf("不支持的同步策略 %q（可选 apply、hook）", o.Strategy)
+	}
+	items := append([]string(nil), o.Options...)
+	if o.ServerSide {
+		items = append(items, "ServerSideApply=true")
+	}
+	if o.ApplyOutOfSyncOnly {
+		items = append(items, "ApplyOutOfSyncOnly=true")
+	}
+	for _, item := range items {
+		if k, v, ok := strings.Cut(item, "="); !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("同步选项 %q 格式错误，应为 Key=value", item)
+		}
+	}
+	if len(items) > 0 {
+		req.SyncOptions = &applications.SyncOptions{Items: items}
+	}
+	if o.RetryLimit > 0 {
+		req.RetryStrategy = &appv1.RetryStrategy{Limit: o.RetryLimit}
+		if o.RetryBackoff > 0 {
+			req.RetryStrategy.Backoff = &appv1.Backoff{Duration: o.RetryBackoff.String()}
+		}
+	}
+	return req, nil
+}
+
+// SyncApplication 触发同步
+func (c *Client) SyncApplication(ctx context.Context, name string, opts SyncOptions) (*appv1.Application, error) {
+	req, err := opts.syncRequest(name)
+	if err != nil {
+		return nil, err
+	}
+	return appCallOnce(ctx, c, "Application.Sync", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*appv1.Application, error) {
+		return appIf.Sync(ctx, req)
+	})
+}
+
+// Validate 在连接服务端前检查参数
+func (o SyncOptions) Validate() error {
+	_, err := o.syncRequest("")
+	return err
+}
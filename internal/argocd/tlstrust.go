@@ -0,0 +1,237 @@
+package argocd
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// CertFingerprint 返回证书 DER 的 SHA-256 指纹（AB:CD:... 形式）
+func CertFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// CertMismatchError 服务端证书与已固定的指纹不一致
+type CertMismatchError struct {
+	Server    string
+	Pinned    string
+	Presented string
+}
+
+func (e *CertMismatchError) Error() string {
+	return fmt.Sprintf("服务端 %s 的证书指纹 %s 与已固定的 %s 不一致，可能遭到中间人攻击；确认证书已合法更换后请删除配置中的固定指纹",
+		e.Server, e.Presented, e.Pinned)
+}
+
+// UntrustedCertError 服务端证书未通过校验且未被信任
+type UntrustedCertError struct {
+	Server      string
+	Fingerprint string
+	Err         error
+}
+
+func (e *UntrustedCertError) Error() string {
+	return fmt.Sprintf("服务端 %s 的证书未通过校验: %v（SHA-256 指纹 %s）；请使用 --server-ca-file 指定 CA，或核对指纹后使用 --trust-on-first-use 固定该证书",
+		e.Server, e.Err, e.Fingerprint)
+}
+
+func (e *UntrustedCertError) Unwrap() error { return e.Err }
+
+// verifyServerCert 在建立 API 连接前握手并校验服务端证书。证书未通过常规校验、但已由固定指纹
+// 或操作者确认信任时返回该证书，调用方需把它作为受信根证书交给实际连接（实际连接始终校验证书）。
+//
+// 顺序：已固定指纹时只比对指纹；否则按系统根证书或 --server-ca-file 校验，
+// 失败时依次尝试 --trust-on-first-use、交互确认，均不可用则返回 *UntrustedCertError。
+func verifyServerCert(ctx context.Context, cfg ClientConfig) (*x509.Certificate, error) {
+	leaf, chain, err := fetchServerCert(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	fp := CertFingerprint(leaf)
+	host, _ := serverHostPort(cfg.ServerAddr)
+
+	if cfg.PinnedFingerprint != "" {
+		if !strings.EqualFold(cfg.PinnedFingerprint, fp) {
+			return nil, &CertMismatchError{Server: cfg.ServerAddr, Pinned: cfg.PinnedFingerprint, Presented: fp}
+		}
+		if err := pinnable(leaf, host); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "[tls] server certificate matches pinned fingerprint %s\n", fp)
+		return leaf, nil
+	}
+
+	verifyErr := verifyChain(cfg, leaf, chain)
+	if verifyErr == nil {
+		return nil, nil
+	}
+	// 固定后实际连接仍校验主机名与有效期，无法通过时不提供信任选项
+	if err := pinnable(leaf, host); err != nil {
+		return nil, &UntrustedCertError{Server: cfg.ServerAddr, Fingerprint: fp, Err: fmt.Errorf("%v；且该证书无法固定: %v", verifyErr, err)}
+	}
+	trusted := false
+	switch {
+	case cfg.TrustOnFirstUse:
+		fmt.Fprintf(os.Stderr, "[tls] trust on first use: pinning certificate %s for %s\n", fp, cfg.ServerAddr)
+		trusted = true
+	case cfg.ConfirmCert != nil:
+		trusted = cfg.ConfirmCert(cfg.ServerAddr, leaf, fp)
+	}
+	if !trusted {
+		return nil, &UntrustedCertError{Server: cfg.ServerAddr, Fingerprint: fp, Err: verifyErr}
+	}
+	if cfg.OnPin != nil {
+		cfg.OnPin(fp)
+	}
+	return leaf, nil
+}
+
+// pinnable 检查以 leaf 自身为根时能否通过校验（主机名、有效期），即实际连接信任它后能否建立
+func pinnable(leaf *x509.Certificate, host string) error {
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+	if _, err := leaf.Verify(x509.VerifyOptions{DNSName: host, Roots: roots}); err != nil {
+		return &Error{Kind: KindTLS, Op: "tls pin", Err: fmt.Errorf("证书无法用于校验实际连接: %w", err)}
+	}
+	return nil
+}
+
+// writeTrustBundle 将 CA 文件（可为空）与固定的证书合并写入临时 PEM 文件，作为实际连接的受信根证书。
+// apiclient 在每次创建连接时读取该文件，调用方需在不再使用 Client 后删除。
+func writeTrustBundle(caFile string, pinned *x509.Certificate) (string, error) {
+	var bundle []byte
+	if caFile != "" {
+		b, err := os.ReadFile(caFile)
+		if err != nil {
+			return "", fmt.Errorf("读取 CA 文件失败: %w", err)
+		}
+		bundle = append(b, '\n')
+	}
+	bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pinned.Raw})...)
+	f, err := os.CreateTemp("", "agt-trust-*.pem")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(bundle); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// serverTLSConfig 与 apiclient 等价的 TLS 配置（系统根证书 + --server-ca-file + 固定的证书、客户端证书、
+// --tls-no-verify），供不经 apiclient 建立的连接使用。ServerName 由调用方按需设置。
+func serverTLSConfig(cfg ClientConfig, pinned *x509.Certificate) (*tls.Config, error) {
+	conf := &tls.Config{InsecureSkipVerify: cfg.TLSNoVerify}
+	if cfg.ServerCAFile != "" || pinned != nil {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if cfg.ServerCAFile != "" {
+			pem, err := os.ReadFile(cfg.ServerCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("读取 CA 文件失败: %w", err)
+			}
+			if !roots.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("CA 文件 %s 中没有有效证书", cfg.ServerCAFile)
+			}
+		}
+		if pinned != nil {
+			roots.AddCert(pinned)
+		}
+		conf.RootCAs = roots
+	}
+	if cfg.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return conf, nil
+}
+
+// fetchServerCert 以不校验的方式握手，取得服务端证书链
+func fetchServerCert(ctx context.Context, cfg ClientConfig) (*x509.Certificate, []*x509.Certificate, error) {
+	host, addr := serverHostPort(cfg.ServerAddr)
+	tlsConf := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true, // 证书由 verifyChain 或固定指纹校验
+	}
+	if cfg.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+	dctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	raw, err := dialServer(dctx, cfg.ProxyURL, addr)
+	if err != nil {
+		return nil, nil, handshakeErr(err)
+	}
+	conn := tls.Client(raw, tlsConf)
+	defer conn.Close()
+	if err := conn.HandshakeContext(dctx); err != nil {
+		return nil, nil, handshakeErr(err)
+	}
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, nil, &Error{Kind: KindTLS, Op: "tls handshake", Err: errors.New("服务端未提供证书")}
+	}
+	return certs[0], certs[1:], nil
+}
+
+// handshakeErr 连接或握手失败；无法归类时视为服务不可达
+func handshakeErr(err error) error {
+	kind := KindOf(err)
+	if kind == KindUnknown {
+		kind = KindUnavailable
+	}
+	return &Error{Kind: kind, Op: "tls handshake", Err: err}
+}
+
+// verifyChain 按系统根证书（或 --server-ca-file）与主机名校验证书链
+func verifyChain(cfg ClientConfig, leaf *x509.Certificate, chain []*x509.Certificate) error {
+	host, _ := serverHostPort(cfg.ServerAddr)
+	opts := x509.VerifyOptions{DNSName: host, Intermediates: x509.NewCertPool()}
+	for _, c := range chain {
+		opts.Intermediates.AddCert(c)
+	}
+	if cfg.ServerCAFile != "" {
+		pem, err := os.ReadFile(cfg.ServerCAFile)
+		if err != nil {
+			return fmt.Errorf("读取 CA 文件失败: %w", err)
+		}
+		opts.Roots = x509.NewCertPool()
+		if !opts.Roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("CA 文件 %s 中没有有效证书", cfg.ServerCAFile)
+		}
+	}
+	_, err := leaf.Verify(opts)
+	return err
+}
+
+// serverHostPort 拆分服务地址，未带端口时默认 443
+func serverHostPort(server string) (string, string) {
+	if host, _, err := net.SplitHostPort(server); err == nil {
+		return host, server
+	}
+	return server, net.JoinHostPort(server, "443")
+}
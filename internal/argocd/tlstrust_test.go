@@ -0,0 +1,124 @@
+package argocd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// selfSigned 生成自签名证书，模拟内部自签的 argocd-server
+func selfSigned(t *testing.T, ips ...net.IP) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "argocd-server"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func tlsServer(t *testing.T, cert tls.Certificate) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// dialWithBundle 以 apiclient 相同的方式（受信根证书 + 校验）建立实际连接
+func dialWithBundle(t *testing.T, addr, bundle string) error {
+	t.Helper()
+	pemData, err := os.ReadFile(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pemData) {
+		t.Fatal("bundle contains no certificate")
+	}
+	conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots})
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func TestPinnedCertEnforcedOnRealConnection(t *testing.T) {
+	loopback := net.ParseIP("127.0.0.1")
+	cert := selfSigned(t, loopback)
+	srv := tlsServer(t, cert)
+	addr := srv.Listener.Addr().String()
+	fp := CertFingerprint(cert.Leaf)
+
+	pinned, err := verifyServerCert(context.Background(), ClientConfig{ServerAddr: addr, PinnedFingerprint: fp})
+	if err != nil {
+		t.Fatalf("verifyServerCert: %v", err)
+	}
+	if pinned == nil {
+		t.Fatal("pinned certificate should be returned for the real connection")
+	}
+	bundle, err := writeTrustBundle("", pinned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bundle)
+	if err := dialWithBundle(t, addr, bundle); err != nil {
+		t.Fatalf("real connection to pinned server failed: %v", err)
+	}
+
+	// 中间人使用同一地址但不同的证书：探测握手报指纹不一致，实际连接也无法通过校验
+	mitm := tlsServer(t, selfSigned(t, loopback))
+	mitmAddr := mitm.Listener.Addr().String()
+	_, err = verifyServerCert(context.Background(), ClientConfig{ServerAddr: mitmAddr, PinnedFingerprint: fp})
+	var mismatch *CertMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("verifyServerCert err = %v, want *CertMismatchError", err)
+	}
+	if err := dialWithBundle(t, mitmAddr, bundle); err == nil {
+		t.Fatal("real connection must reject a certificate other than the pinned one")
+	}
+}
+
+func TestUnpinnableCertNotOffered(t *testing.T) {
+	// 证书不含连接地址，固定后实际连接也会失败，因此不应提供信任
+	srv := tlsServer(t, selfSigned(t, net.ParseIP("10.0.0.1")))
+	_, err := verifyServerCert(context.Background(), ClientConfig{
+		ServerAddr:      srv.Listener.Addr().String(),
+		TrustOnFirstUse: true,
+		OnPin:           func(string) { t.Error("certificate must not be pinned") },
+	})
+	var untrusted *UntrustedCertError
+	if !errors.As(err, &untrusted) || !strings.Contains(err.Error(), "无法固定") {
+		t.Fatalf("err = %v, want *UntrustedCertError mentioning the pin", err)
+	}
+}
@@ -0,0 +1,192 @@
+package argocd
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+	"google.golang.org/grpc/metadata"
+)
+
+// parseHeaders 解析 "Name=value" 形式的附加请求头，返回 http.Header 及 apiclient 需要的 "Name:value" 列表
+func parseHeaders(kvs []string) (http.Header, []string, error) {
+	header := http.Header{}
+	var apiHeaders []string
+	for _, kv := range kvs {
+		name, value, ok := strings.Cut(kv, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, nil, fmt.Errorf("请求头 %q 格式错误，应为 Name=value", kv)
+		}
+		header.Add(name, value)
+		apiHeaders = append(apiHeaders, name+":"+value)
+	}
+	return header, apiHeaders, nil
+}
+
+// outgoingHeaders 将附加请求头作为 gRPC metadata 附加到 ctx。
+// apiclient 在 grpc-web 模式下由本地代理为每个请求添加请求头，gRPC 模式下则需要由调用方附加。
+func outgoingHeaders(ctx context.Context, md metadata.MD) context.Context {
+	if len(md) == 0 {
+		return ctx
+	}
+	return metadata.NewOutgoingContext(ctx, metadata.Join(metadata.MD{}, md, existingMD(ctx)))
+}
+
+func existingMD(ctx context.Context) metadata.MD {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return md
+}
+
+// rpcContext 为直接发起的 gRPC 调用附加请求头
+func (c *Client) rpcContext(ctx context.Context) context.Context {
+	return outgoingHeaders(ctx, c.rpcHeaders)
+}
+
+// headerMetadata gRPC 模式下需要随每个请求发送的 metadata；grpc-web 模式返回空
+func headerMetadata(header http.Header, grpcWeb bool) metadata.MD {
+	if grpcWeb || len(header) == 0 {
+		return nil
+	}
+	md := metadata.MD{}
+	for k, vs := range header {
+		md.Append(k, vs...)
+	}
+	return md
+}
+
+// proxyDialer 根据 --proxy-url 构造拨号器，只用于本客户端自身的连接，不修改进程环境变量
+func proxyDialer(proxyURL string) (proxy.ContextDialer, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("代理地址 %q 无效: %w", proxyURL, err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("代理地址 %q 缺少主机", proxyURL)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return &connectDialer{proxyURL: u, forward: proxy.Direct}, nil
+	case "socks5", "socks5h":
+		d, err := proxy.FromURL(u, proxy.Direct)
+		if err != nil {
+			return nil, fmt.Errorf("代理地址 %q 无效: %w", proxyURL, err)
+		}
+		return d.(proxy.ContextDialer), nil
+	}
+	return nil, fmt.Errorf("代理地址 %q 不受支持，协议应为 http、https 或 socks5", proxyURL)
+}
+
+// redactURL 隐藏地址中的密码，用于日志输出
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Redacted()
+}
+
+// dialServer 建立到 Argo CD 的 TCP 连接：指定 --proxy-url 时经由该代理，
+// 否则与 apiclient 的 gRPC 拨号一致（x/net/proxy，遵循 ALL_PROXY）
+func dialServer(ctx context.Context, proxyURL, addr string) (net.Conn, error) {
+	if proxyURL == "" {
+		return proxy.Dial(ctx, "tcp", addr)
+	}
+	d, err := proxyDialer(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	return d.DialContext(ctx, "tcp", addr)
+}
+
+// connectDialer 通过 HTTP CONNECT 隧道拨号
+type connectDialer struct {
+	proxyURL *url.URL
+	forward  proxy.Dialer
+}
+
+func (d *connectDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+func (d *connectDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	proxyAddr := d.proxyURL.Host
+	if d.proxyURL.Port() == "" {
+		port := "80"
+		if d.proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(d.proxyURL.Hostname(), port)
+	}
+	var conn net.Conn
+	var err error
+	if cd, ok := d.forward.(proxy.ContextDialer); ok {
+		conn, err = cd.DialContext(ctx, network, proxyAddr)
+	} else {
+		conn, err = d.forward.Dial(network, proxyAddr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: d.proxyURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer func() { _ = conn.SetDeadline(time.Time{}) }()
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if u := d.proxyURL.User; u != nil {
+		pass, _ := u.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(u.Username() + ":" + pass))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("代理 CONNECT %s 失败: %s", addr, resp.Status)
+	}
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn 读取时先消费 CONNECT 响应后已缓冲的数据
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
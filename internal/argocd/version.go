@@ -0,0 +1,45 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient/version"
+	"golang.org/x/mod/semver"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// 依赖的 argo-cd/v2 客户端（v2.14）经过验证的服务端版本范围（按 major.minor 比较）
+const (
+	MinTestedServerVersion = "v2.10"
+	MaxTestedServerVersion = "v2.14"
+)
+
+// Version 通过 Version 服务读取服务端版本、构建时间及 Kustomize/Helm 等工具版本
+func (c *Client) Version(ctx context.Context) (*version.VersionMessage, error) {
+	return withRetry(ctx, c.retry, "Version.Version", func() (*version.VersionMessage, error) {
+		return withReauth(ctx, c, "Version.Version", func(ctx context.Context) (*version.VersionMessage, error) {
+			verIf, err := c.versionClient()
+			if err != nil {
+				return nil, err
+			}
+			return verIf.Version(ctx, &emptypb.Empty{})
+		})
+	})
+}
+
+// CompatibilityWarning 服务端版本超出验证范围时返回提示，否则返回空字符串
+func CompatibilityWarning(serverVersion string) string {
+	v := serverVersion
+	if v != "" && v[0] != 'v' {
+		v = "v" + v
+	}
+	if !semver.IsValid(v) {
+		return fmt.Sprintf("无法解析服务端版本 %q，无法确认与客户端（验证范围 %s ~ %s）是否兼容", serverVersion, MinTestedServerVersion, MaxTestedServerVersion)
+	}
+	mm := semver.MajorMinor(v)
+	if semver.Compare(mm, MinTestedServerVersion) < 0 || semver.Compare(mm, MaxTestedServerVersion) > 0 {
+		return fmt.Sprintf("服务端版本 %s 超出验证范围 %s ~ %s，部分功能可能不兼容", serverVersion, MinTestedServerVersion, MaxTestedServerVersion)
+	}
+	return ""
+}
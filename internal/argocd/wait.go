@@ -0,0 +1,411 @@
+package argocd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	applications "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/argoproj/gitops-engine/pkg/health"
+	synccommon "github.com/argoproj/gitops-engine/pkg/sync/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// WaitOptions 等待应用达到的条件，全部满足时结束
+type WaitOptions struct {
+	// Health 可接受的健康状态，为空时不检查
+	Health []health.HealthStatusCode
+	// Sync 要求 Synced
+	Sync bool
+	// Operation 要求没有进行中的操作，且最近一次操作成功
+	Operation bool
+	// WorkloadsScaledToZero 要求全部可缩容工作负载 spec.replicas 为 0 且没有 Pod
+	WorkloadsScaledToZero bool
+	// PodsReady 要求资源树中的全部 Pod 健康（Running 且就绪）
+	PodsReady bool
+	// Timeout 等待时长，0 表示只受 ctx 限制
+	Timeout time.Duration
+}
+
+// ParseWaitFor 解析 --wait-for 的条件列表（health、sync、operation）
+func ParseWaitFor(items []string) (WaitOptions, error) {
+	var opts WaitOptions
+	for _, item := range items {
+		switch strings.ToLower(strings.TrimSpace(item)) {
+		case "health":
+			opts.Health = []health.HealthStatusCode{health.HealthStatusHealthy}
+		case "sync":
+			opts.Sync = true
+		case "operation":
+			opts.Operation = true
+		case "":
+		default:
+			return opts, fmt.Errorf("不支持的等待条件 %q（可选 health、sync、operation）", item)
+		}
+	}
+	return opts, nil
+}
+
+var healthStatuses = []health.HealthStatusCode{
+	health.HealthStatusHealthy,
+	health.HealthStatusProgressing,
+	health.HealthStatusDegraded,
+	health.HealthStatusSuspended,
+	health.HealthStatusMissing,
+	health.HealthStatusUnknown,
+}
+
+// ParseHealth 解析健康状态名称（不区分大小写）
+func ParseHealth(s string) (health.HealthStatusCode, error) {
+	for _, h := range healthStatuses {
+		if strings.EqualFold(s, string(h)) {
+			return h, nil
+		}
+	}
+	return "", fmt.Errorf("不支持的健康状态 %q（可选 Healthy、Progressing、Degraded、Suspended、Missing、Unknown）", s)
+}
+
+// Empty 未指定任何条件
+func (o WaitOptions) Empty() bool {
+	return len(o.Health) == 0 && !o.Sync && !o.Operation && !o.WorkloadsScaledToZero && !o.PodsReady
+}
+
+func (o WaitOptions) needTree() bool {
+	return o.WorkloadsScaledToZero || o.PodsReady
+}
+
+// WaitForApp 监听应用变化直到满足 opts 的全部条件，期间输出应用与各资源的同步、健康进度。
+// 操作失败，或没有进行中的操作而应用 Degraded（且 Degraded 不在可接受状态内）时立即返回错误。
+func (c *Client) WaitForApp(ctx context.Context, name string, opts WaitOptions) error {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ch := c.apiConn().WatchApplicationWithRetry(c.rpcContext(watchCtx), name, "")
+
+	progress := newWaitProgress(name)
+	// 资源树条件在收到第一个应用事件（得到 project）后订阅资源树，并定期轮询兜底
+	var trees <-chan *appv1.ApplicationTree
+	var tick <-chan time.Time
+	if opts.needTree() {
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	var last *appv1.Application
+	var tree *appv1.ApplicationTree
+	for {
+		select {
+		case <-ctx.Done():
+			return waitErr(name, last, ctx.Err())
+		case ev, ok := <-ch:
+			if !ok {
+				if ctx.Err() != nil {
+					return waitErr(name, last, ctx.Err())
+				}
+				return fmt.Errorf("等待应用 %s 时监听已关闭", name)
+			}
+			// app 是值类型，无法与 nil 比较；检查名称是否为空来过滤无效事件
+			if ev == nil || ev.Application.Name == "" {
+				continue
+			}
+			app := ev.Application
+			last = &app
+			progress.report(&app)
+			if opts.needTree() && tree == nil {
+				t, err := c.resourceTree(ctx, app.Spec.Project, name)
+				if err != nil {
+					return err
+				}
+				tree = t
+				trees = c.watchResourceTree(watchCtx, app.Spec.Project, name, "wait")
+			}
+		case t := <-trees:
+			tree = t
+		case <-tick:
+			if last == nil {
+				continue
+			}
+			t, err := c.resourceTree(ctx, last.Spec.Project, name)
+			if err != nil {
+				if ctx.Err() != nil {
+					continue
+				}
+				return err
+			}
+			tree = t
+		}
+		if last == nil {
+			continue
+		}
+		done, err := opts.check(last)
+		if err != nil {
+			return err
+		}
+		if done && opts.needTree() {
+			done = c.treeConditionsMet(ctx, last, tree, opts, progress)
+		}
+		if done {
+			fmt.Fprintf(os.Stderr, "[wait] %s: %s, conditions met\n", name, appSummary(last))
+			return nil
+		}
+	}
+}
+
+func (c *Client) resourceTree(ctx context.Context, project, appName string) (*appv1.ApplicationTree, error) {
+	return appCall(ctx, c, "Application.ResourceTree", func(ctx context.Context, appIf applications.ApplicationServiceClient) (*appv1.ApplicationTree, error) {
+		return appIf.ResourceTree(ctx, &applications.ResourcesQuery{Project: &project, ApplicationName: &appName})
+	})
+}
+
+// treeConditionsMet 检查 Pod 就绪与工作负载置 0 条件，并输出进度
+func (c *Client) treeConditionsMet(ctx context.Context, app *appv1.Application, tree *appv1.ApplicationTree, opts WaitOptions, progress *waitProgress) bool {
+	if tree == nil {
+		return false
+	}
+	if opts.PodsReady {
+		total, ready := 0, 0
+		for _, n := range tree.Nodes {
+			if n.Kind != "Pod" {
+				continue
+			}
+			total++
+			if n.Health != nil && n.Health.Status == health.HealthStatusHealthy {
+				ready++
+			}
+		}
+		progress.note("pods", fmt.Sprintf("pods ready %d/%d", ready, total))
+		if ready < total {
+			return false
+		}
+	}
+	if opts.WorkloadsScaledToZero {
+		var workloads []appv1.ResourceStatus
+		for _, r := range app.Status.Resources {
+			if _, ok := canScaleWorkloads[r.Kind]; ok {
+				workloads = append(workloads, r)
+			}
+		}
+		// 先按资源树判断 Pod 是否已全部删除，满足后再回读 spec.replicas
+		atZero := 0
+		for i := range workloads {
+			r := &workloads[i]
+			if node := tree.FindNode(r.Group, r.Kind, r.Namespace, r.Name); node != nil && len(workloadPods(tree, node)) > 0 {
+				continue
+			}
+			atZero++
+		}
+		if atZero == len(workloads) {
+			for i := range workloads {
+				obj, err := c.getLiveResource(ctx, app.Spec.Project, app.Name, &workloads[i])
+				if err != nil {
+					if IsResourceNotInApp(err) {
+						continue
+					}
+					atZero--
+					continue
+				}
+				if zero, err := scaledToZero(obj); err != nil || !zero {
+					atZero--
+				}
+			}
+		}
+		progress.note("workloads", fmt.Sprintf("workloads scaled to zero %d/%d", atZero, len(workloads)))
+		if atZero < len(workloads) {
+			return false
+		}
+	}
+	return true
+}
+
+// scaledToZero 判断工作负载 spec.replicas 是否为 0（未设置时默认 1，不算置 0）
+func scaledToZero(obj *unstructured.Unstructured) (bool, error) {
+	replicas, err := specReplicas(obj)
+	if err != nil {
+		return false, err
+	}
+	return replicas == 0, nil
+}
+
+// check 判断条件是否满足；需要立即失败时返回错误
+func (o WaitOptions) check(app *appv1.Application) (bool, error) {
+	inProgress := operationInProgress(app)
+	if o.Operation && !inProgress {
+		if err := operationFailure(app); err != nil {
+			return false, err
+		}
+	}
+	healthy := len(o.Health) == 0 || containsHealth(o.Health, app.Status.Health.Status)
+	if !healthy && !inProgress && app.Status.Health.Status == health.HealthStatusDegraded {
+		return false, degradedError(app)
+	}
+	if !healthy {
+		return false, nil
+	}
+	if o.Sync && app.Status.Sync.Status != appv1.SyncStatusCodeSynced {
+		return false, nil
+	}
+	if o.Operation && inProgress {
+		return false, nil
+	}
+	return true, nil
+}
+
+func containsHealth(list []health.HealthStatusCode, s health.HealthStatusCode) bool {
+	for _, h := range list {
+		if strings.EqualFold(string(h), string(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// operationInProgress 已请求但控制器尚未开始，或正在执行的操作
+func operationInProgress(app *appv1.Application) bool {
+	if app.Operation != nil {
+		return true
+	}
+	return app.Status.OperationState != nil && !app.Status.OperationState.Phase.Completed()
+}
+
+// operationPhase 操作阶段；已请求但尚未开始时为 Pending
+func operationPhase(app *appv1.Application) string {
+	st := app.Status.OperationState
+	if app.Operation != nil && (st == nil || st.Phase.Completed()) {
+		return "Pending"
+	}
+	if st == nil {
+		return "-"
+	}
+	return string(st.Phase)
+}
+
+// operationFailure 最近一次操作失败时返回包含失败资源信息的错误
+func operationFailure(app *appv1.Application) error {
+	st := app.Status.OperationState
+	if st == nil || (st.Phase != synccommon.OperationFailed && st.Phase != synccommon.OperationError) {
+		return nil
+	}
+	lines := []string{fmt.Sprintf("应用 %s 的同步操作 %s: %s", app.Name, st.Phase, st.Message)}
+	if st.SyncResult != nil {
+		for _, r := range st.SyncResult.Resources {
+			if r.Status == synccommon.ResultCodeSyncFailed || r.HookPhase == synccommon.OperationFailed || r.HookPhase == synccommon.OperationError {
+				lines = append(lines, fmt.Sprintf("  %s %s/%s: %s", r.Kind, r.Namespace, r.Name, r.Message))
+			}
+		}
+	}
+	return errors.New(strings.Join(lines, "\n"))
+}
+
+// degradedError 应用 Degraded 时列出不健康的资源
+func degradedError(app *appv1.Application) error {
+	lines := []string{fmt.Sprintf("应用 %s 处于 Degraded: %s", app.Name, app.Status.Health.Message)}
+	for _, r := range app.Status.Resources {
+		if r.Health != nil && r.Health.Status == health.HealthStatusDegraded {
+			lines = append(lines, fmt.Sprintf("  %s %s/%s: %s", r.Kind, r.Namespace, r.Name, r.Health.Message))
+		}
+	}
+	return errors.New(strings.Join(lines, "\n"))
+}
+
+// waitErr 等待被 ctx 结束：超时时附带最后观察到的状态
+func waitErr(name string, last *appv1.Application, err error) error {
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	state := "未收到应用状态"
+	if last != nil {
+		state = appSummary(last)
+	}
+	return &Error{Kind: KindTimeout, Op: "wait " + name, Err: fmt.Errorf("等待超时，最后状态 %s", state)}
+}
+
+func appSummary(app *appv1.Application) string {
+	return fmt.Sprintf("sync=%s health=%s operation=%s", app.Status.Sync.Status, app.Status.Health.Status, operationPhase(app))
+}
+
+// waitProgress 只输出发生变化的应用与资源状态
+type waitProgress struct {
+	name      string
+	summary   string
+	resources map[string]string
+	notes     map[string]string
+}
+
+func newWaitProgress(name string) *waitProgress {
+	return &waitProgress{name: name, resources: map[string]string{}, notes: map[string]string{}}
+}
+
+// note 输出资源树条件的进度，与上次相同时不重复输出
+func (p *waitProgress) note(key, msg string) {
+	if p.notes[key] != msg {
+		p.notes[key] = msg
+		fmt.Fprintf(os.Stderr, "[wait] %s: %s\n", p.name, msg)
+	}
+}
+
+type resourceProgress struct {
+	kind, namespace, name string
+	sync, health, message string
+}
+
+func (p *waitProgress) report(app *appv1.Application) {
+	if s := appSummary(app); s != p.summary {
+		p.summary = s
+		fmt.Fprintf(os.Stderr, "[wait] %s: %s\n", p.name, s)
+	}
+
+	var keys []string
+	byKey := map[string]*resourceProgress{}
+	get := func(group, kind, ns, name string) *resourceProgress {
+		k := group + "/" + kind + "/" + ns + "/" + name
+		if r, ok := byKey[k]; ok {
+			return r
+		}
+		r := &resourceProgress{kind: kind, namespace: ns, name: name, sync: "-", health: "-"}
+		byKey[k] = r
+		keys = append(keys, k)
+		return r
+	}
+	for _, res := range app.Status.Resources {
+		r := get(res.Group, res.Kind, res.Namespace, res.Name)
+		r.sync = string(res.Status)
+		if res.Health != nil && res.Health.Status != "" {
+			r.health = string(res.Health.Status)
+			r.message = res.Health.Message
+		}
+	}
+	// 尚未开始的操作不展示上一次操作的结果
+	if st := app.Status.OperationState; st != nil && st.SyncResult != nil && operationPhase(app) != "Pending" {
+		for _, res := range st.SyncResult.Resources {
+			r := get(res.Group, res.Kind, res.Namespace, res.Name)
+			switch {
+			case res.HookType != "":
+				r.sync = string(res.HookType) + "/" + string(res.HookPhase)
+			case res.Status != "":
+				r.sync = string(res.Status)
+			}
+			if res.Message != "" {
+				r.message = res.Message
+			}
+		}
+	}
+	for _, k := range keys {
+		r := byKey[k]
+		line := fmt.Sprintf("%s %s/%s sync=%s health=%s", r.kind, r.namespace, r.name, r.sync, r.health)
+		if r.message != "" {
+			line += " " + r.message
+		}
+		if p.resources[k] != line {
+			p.resources[k] = line
+			fmt.Fprintf(os.Stderr, "[wait] %s:   %s\n", p.name, line)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package argocd
+
+import "testing"
+
+func TestScaledToZero(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     bool
+	}{
+		{name: "scaled down", manifest: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"gate","namespace":"game"},"spec":{"replicas":0,"selector":{"matchLabels":{"app":"gate"}}},"status":{"observedGeneration":4}}`, want: true},
+		{name: "running", manifest: deploymentManifest, want: false},
+		{name: "replicas unset", manifest: `{"apiVersion":"apps/v1","kind":"StatefulSet","metadata":{"name":"db","namespace":"game"},"spec":{"serviceName":"db"}}`, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := decodeManifest(tt.manifest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := scaledToZero(obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("scaledToZero = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
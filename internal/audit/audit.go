@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/config"
+)
+
+// Entry 一条审计记录
+type Entry struct {
+	Time          time.Time `json:"time"`
+	Subject       string    `json:"subject"`
+	Server        string    `json:"server,omitempty"`
+	App           string    `json:"app"`
+	Project       string    `json:"project,omitempty"`
+	Operation     string    `json:"operation"`
+	Event         string    `json:"event"`
+	Justification string    `json:"justification,omitempty"`
+	Details       []string  `json:"details,omitempty"`
+}
+
+// DefaultPath 返回审计日志路径，可通过 AGT_AUDIT_LOG 覆盖
+func DefaultPath() string {
+	if p := os.Getenv("AGT_AUDIT_LOG"); p != "" {
+		return p
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		return "agt-audit.log"
+	}
+	return filepath.Join(dir, "audit.log")
+}
+
+// Record 以 JSON Lines 追加写入审计日志
+func Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	p := DefaultPath()
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return fmt.Errorf("创建审计日志目录失败: %w", err)
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("打开审计日志失败: %w", err)
+	}
+	defer f.Close()
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,59 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// 以下变量在发布构建时通过 -ldflags "-X" 注入
+var (
+	Version   = "dev"
+	GitCommit = ""
+	BuildDate = ""
+)
+
+// ArgoCDModule 依赖的 Argo CD 客户端模块
+const ArgoCDModule = "github.com/argoproj/argo-cd/v2"
+
+// Info 客户端构建信息
+type Info struct {
+	Version       string `json:"version"`
+	GitCommit     string `json:"gitCommit,omitempty"`
+	BuildDate     string `json:"buildDate,omitempty"`
+	GoVersion     string `json:"goVersion"`
+	Platform      string `json:"platform"`
+	ArgoCDVersion string `json:"argocdClientVersion,omitempty"`
+}
+
+// Get 返回构建信息；未通过 ldflags 注入提交号时回退到 Go 记录的 VCS 信息
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, dep := range bi.Deps {
+		if dep.Path == ArgoCDModule {
+			info.ArgoCDVersion = dep.Version
+		}
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if info.GitCommit == "" {
+				info.GitCommit = s.Value
+			}
+		case "vcs.time":
+			if info.BuildDate == "" {
+				info.BuildDate = s.Value
+			}
+		}
+	}
+	return info
+}
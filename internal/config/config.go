@@ -0,0 +1,236 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Config agt 本地配置文件（默认 ~/.config/agt/config.yaml）
+type Config struct {
+	CurrentContext string    `json:"current-context,omitempty"`
+	Contexts       []Context `json:"contexts,omitempty"`
+	Sessions       []Session `json:"sessions,omitempty"`
+	// PinnedCerts 首次信任（TOFU）或交互确认后固定的服务端证书指纹
+	PinnedCerts []PinnedCert `json:"pinnedCerts,omitempty"`
+
+	// path 为加载该配置的文件路径，Save 时写回
+	path string
+}
+
+// Context 一个命名的 Argo CD 实例连接配置
+type Context struct {
+	Name                 string      `json:"name"`
+	Server               string      `json:"server"`
+	Insecure             bool        `json:"insecure,omitempty"`
+	TLSNoVerify          bool        `json:"tlsNoVerify,omitempty"`
+	GRPCWeb              bool        `json:"grpcWeb,omitempty"`
+	GRPCWebRoot          string      `json:"grpcWebRootPath,omitempty"`
+	ServerCAFile         string      `json:"serverCAFile,omitempty"`
+	ClientCert           string      `json:"clientCert,omitempty"`
+	ClientKey            string      `json:"clientKey,omitempty"`
+	Headers              []string    `json:"headers,omitempty"`
+	ProxyURL             string      `json:"proxyURL,omitempty"`
+	PortForward          bool        `json:"portForward,omitempty"`
+	PortForwardNamespace string      `json:"portForwardNamespace,omitempty"`
+	KubeContext          string      `json:"kubeContext,omitempty"`
+	Project              string      `json:"project,omitempty"`
+	Credentials          Credentials `json:"credentials,omitempty"`
+}
+
+// Session agt login 获得的会话 token，按服务地址保存
+type Session struct {
+	Server    string `json:"server"`
+	Username  string `json:"username,omitempty"`
+	AuthToken string `json:"authToken"`
+}
+
+// PinnedCert 按服务地址固定的证书 SHA-256 指纹
+type PinnedCert struct {
+	Server      string `json:"server"`
+	Fingerprint string `json:"sha256"`
+}
+
+// Credentials 凭据引用：配置文件中不保存明文密码，只记录从哪里读取
+type Credentials struct {
+	// TokenEnv 从该环境变量读取 token
+	TokenEnv string `json:"tokenEnv,omitempty"`
+	// TokenFile 从该文件读取 token
+	TokenFile string `json:"tokenFile,omitempty"`
+	Username  string `json:"username,omitempty"`
+	// PasswordEnv 从该环境变量读取密码
+	PasswordEnv string `json:"passwordEnv,omitempty"`
+}
+
+// Dir 返回 agt 配置目录 ~/.config/agt
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "agt"), nil
+}
+
+// DefaultPath 返回配置文件路径，可通过 AGT_CONFIG 覆盖
+func DefaultPath() (string, error) {
+	if p := os.Getenv("AGT_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// Load 读取配置文件，文件不存在时返回空配置
+func Load(path string) (*Config, error) {
+	cfg := &Config{path: path}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("读取配置文件 %s 失败: %w", path, err)
+	}
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save 写回配置文件，权限 0600（可能包含 token）
+func (c *Config) Save() error {
+	if c.path == "" {
+		return fmt.Errorf("配置文件路径为空")
+	}
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("创建配置目录失败: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// Path 返回配置文件路径
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Context 按名称查找上下文
+func (c *Config) Context(name string) (*Context, bool) {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			return &c.Contexts[i], true
+		}
+	}
+	return nil, false
+}
+
+// SetContext 新增或替换同名上下文，并保持按名称排序
+func (c *Config) SetContext(ctx Context) {
+	if existing, ok := c.Context(ctx.Name); ok {
+		*existing = ctx
+		return
+	}
+	c.Contexts = append(c.Contexts, ctx)
+	sort.Slice(c.Contexts, func(i, j int) bool { return c.Contexts[i].Name < c.Contexts[j].Name })
+}
+
+// DeleteContext 删除上下文；删除的是当前上下文时清空 current-context
+func (c *Config) DeleteContext(name string) bool {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			c.Contexts = append(c.Contexts[:i], c.Contexts[i+1:]...)
+			if c.CurrentContext == name {
+				c.CurrentContext = ""
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Session 按服务地址查找已保存的会话
+func (c *Config) Session(server string) (*Session, bool) {
+	for i := range c.Sessions {
+		if c.Sessions[i].Server == server {
+			return &c.Sessions[i], true
+		}
+	}
+	return nil, false
+}
+
+// SetSession 新增或替换服务地址对应的会话
+func (c *Config) SetSession(s Session) {
+	if existing, ok := c.Session(s.Server); ok {
+		*existing = s
+		return
+	}
+	c.Sessions = append(c.Sessions, s)
+}
+
+// DeleteSession 删除服务地址对应的会话
+func (c *Config) DeleteSession(server string) bool {
+	for i := range c.Sessions {
+		if c.Sessions[i].Server == server {
+			c.Sessions = append(c.Sessions[:i], c.Sessions[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// PinnedCert 按服务地址查找固定的证书指纹
+func (c *Config) PinnedCert(server string) (*PinnedCert, bool) {
+	for i := range c.PinnedCerts {
+		if c.PinnedCerts[i].Server == server {
+			return &c.PinnedCerts[i], true
+		}
+	}
+	return nil, false
+}
+
+// SetPinnedCert 新增或替换服务地址对应的证书指纹
+func (c *Config) SetPinnedCert(p PinnedCert) {
+	if existing, ok := c.PinnedCert(p.Server); ok {
+		*existing = p
+		return
+	}
+	c.PinnedCerts = append(c.PinnedCerts, p)
+}
+
+// Token 按凭据引用读取 token，未配置时返回空
+func (cr *Credentials) Token() (string, error) {
+	if cr.TokenEnv != "" {
+		if v := os.Getenv(cr.TokenEnv); v != "" {
+			return v, nil
+		}
+	}
+	if cr.TokenFile != "" {
+		b, err := os.ReadFile(cr.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("读取 token 文件 %s 失败: %w", cr.TokenFile, err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	return "", nil
+}
+
+// Password 按凭据引用读取密码
+func (cr *Credentials) Password() string {
+	if cr.PasswordEnv == "" {
+		return ""
+	}
+	return os.Getenv(cr.PasswordEnv)
+}
@@ -0,0 +1,240 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/yafeiaa/argocd-game-tools/internal/config"
+)
+
+// RepoFile 仓库级策略文件名（在当前目录查找）
+const RepoFile = ".agt-policy.yaml"
+
+// Policy 受保护应用策略
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule 一条保护规则；Apps/Projects/Destinations 支持 path.Match 通配，留空表示不限
+type Rule struct {
+	Name            string   `json:"name"`
+	Apps            []string `json:"apps,omitempty"`
+	Projects        []string `json:"projects,omitempty"`
+	Destinations    []string `json:"destinations,omitempty"`
+	AllowedSubjects []string `json:"allowedSubjects,omitempty"`
+	Windows         []Window `json:"windows,omitempty"`
+	MaxWorkloads    int      `json:"maxWorkloads,omitempty"`
+}
+
+// Window 允许操作的时间窗口，End 早于 Start 表示跨零点
+type Window struct {
+	Days     []string `json:"days,omitempty"`
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+	Timezone string   `json:"timezone,omitempty"`
+}
+
+// Target 一次受保护操作的描述
+type Target struct {
+	App        string
+	Project    string
+	DestServer string
+	DestName   string
+	Subject    string
+	Operation  string
+	// Workloads 为本次涉及的工作负载数，小于 0 表示不适用
+	Workloads int
+}
+
+// Violation 违反的规则及原因
+type Violation struct {
+	Rule   string
+	Reason string
+}
+
+// DeniedError 策略拒绝执行
+type DeniedError struct {
+	Target     Target
+	Violations []Violation
+}
+
+func (e *DeniedError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "策略拒绝对应用 %s 执行 %s：", e.Target.App, e.Target.Operation)
+	for _, v := range e.Violations {
+		fmt.Fprintf(&b, "\n  - [%s] %s", v.Rule, v.Reason)
+	}
+	b.WriteString("\n如确需执行，请使用 --override-policy 并通过 --justification 说明原因")
+	return b.String()
+}
+
+// DefaultFiles 返回默认查找的策略文件：仓库级优先，其次为用户级
+func DefaultFiles() []string {
+	files := []string{RepoFile}
+	if dir, err := config.Dir(); err == nil {
+		files = append(files, filepath.Join(dir, "policy.yaml"))
+	}
+	return files
+}
+
+// Load 读取策略文件。file 非空时必须存在；为空时依次查找 DefaultFiles，都不存在则返回空策略
+func Load(file string) (*Policy, string, error) {
+	candidates := DefaultFiles()
+	if file != "" {
+		candidates = []string{file}
+	}
+	for _, f := range candidates {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			if os.IsNotExist(err) && file == "" {
+				continue
+			}
+			return nil, "", fmt.Errorf("读取策略文件 %s 失败: %w", f, err)
+		}
+		p := &Policy{}
+		if err := yaml.UnmarshalStrict(b, p); err != nil {
+			return nil, "", fmt.Errorf("解析策略文件 %s 失败: %w", f, err)
+		}
+		return p, f, nil
+	}
+	return &Policy{}, "", nil
+}
+
+// Evaluate 对目标逐条评估匹配的规则，返回全部违反项
+func (p *Policy) Evaluate(t Target, now time.Time) ([]Violation, error) {
+	var out []Violation
+	for _, r := range p.Rules {
+		if !r.matches(t) {
+			continue
+		}
+		name := r.Name
+		if name == "" {
+			name = "unnamed"
+		}
+		if len(r.AllowedSubjects) > 0 && !matchAny(r.AllowedSubjects, t.Subject) {
+			out = append(out, Violation{Rule: name, Reason: fmt.Sprintf("主体 %q 不在允许列表 %v 中", t.Subject, r.AllowedSubjects)})
+		}
+		if len(r.Windows) > 0 {
+			ok, err := inAnyWindow(r.Windows, now)
+			if err != nil {
+				return nil, fmt.Errorf("规则 %s: %w", name, err)
+			}
+			if !ok {
+				out = append(out, Violation{Rule: name, Reason: fmt.Sprintf("当前时间 %s 不在允许的时间窗口内", now.Format(time.RFC3339))})
+			}
+		}
+		if r.MaxWorkloads > 0 && t.Workloads > r.MaxWorkloads {
+			out = append(out, Violation{Rule: name, Reason: fmt.Sprintf("本次涉及 %d 个工作负载，超过上限 %d", t.Workloads, r.MaxWorkloads)})
+		}
+	}
+	return out, nil
+}
+
+// Check 评估策略，有违反项时返回 *DeniedError
+func (p *Policy) Check(t Target, now time.Time) error {
+	violations, err := p.Evaluate(t, now)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return &DeniedError{Target: t, Violations: violations}
+	}
+	return nil
+}
+
+func (r *Rule) matches(t Target) bool {
+	if len(r.Apps) > 0 && !matchAny(r.Apps, t.App) {
+		return false
+	}
+	if len(r.Projects) > 0 && !matchAny(r.Projects, t.Project) {
+		return false
+	}
+	if len(r.Destinations) > 0 && !matchAny(r.Destinations, t.DestServer) && !matchAny(r.Destinations, t.DestName) {
+		return false
+	}
+	return true
+}
+
+func matchAny(patterns []string, s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func inAnyWindow(windows []Window, now time.Time) (bool, error) {
+	for _, w := range windows {
+		ok, err := w.contains(now)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (w *Window) contains(now time.Time) (bool, error) {
+	loc := time.Local
+	if w.Timezone != "" {
+		l, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return false, fmt.Errorf("无效的时区 %q: %w", w.Timezone, err)
+		}
+		loc = l
+	}
+	now = now.In(loc)
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return false, err
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return false, err
+	}
+	cur := now.Hour()*60 + now.Minute()
+	day := now
+	var in bool
+	if start <= end {
+		in = cur >= start && cur < end
+	} else {
+		// 跨零点：零点后的部分归属于前一天的窗口
+		in = cur >= start || cur < end
+		if cur < end {
+			day = now.AddDate(0, 0, -1)
+		}
+	}
+	if !in {
+		return false, nil
+	}
+	if len(w.Days) == 0 {
+		return true, nil
+	}
+	for _, d := range w.Days {
+		if strings.EqualFold(d, day.Weekday().String()[:3]) || strings.EqualFold(d, day.Weekday().String()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// parseClock 将 "HH:MM" 解析为当天的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("无效的时间 %q，应为 HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,187 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 2024-01-05 为星期五
+func at(t *testing.T, clock string, loc *time.Location) time.Time {
+	t.Helper()
+	c, err := time.ParseInLocation("2006-01-02 15:04", "2024-01-05 "+clock, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestWindowContains(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	cases := []struct {
+		name string
+		w    Window
+		now  time.Time
+		want bool
+	}{
+		{"inside", Window{Start: "02:00", End: "06:00", Timezone: "UTC"}, at(t, "03:00", time.UTC), true},
+		{"at start", Window{Start: "02:00", End: "06:00", Timezone: "UTC"}, at(t, "02:00", time.UTC), true},
+		{"end exclusive", Window{Start: "02:00", End: "06:00", Timezone: "UTC"}, at(t, "06:00", time.UTC), false},
+		{"before", Window{Start: "02:00", End: "06:00", Timezone: "UTC"}, at(t, "01:59", time.UTC), false},
+		{"day matches", Window{Days: []string{"Fri"}, Start: "02:00", End: "06:00", Timezone: "UTC"}, at(t, "03:00", time.UTC), true},
+		{"full day name", Window{Days: []string{"friday"}, Start: "02:00", End: "06:00", Timezone: "UTC"}, at(t, "03:00", time.UTC), true},
+		{"day differs", Window{Days: []string{"Tue", "Thu"}, Start: "02:00", End: "06:00", Timezone: "UTC"}, at(t, "03:00", time.UTC), false},
+		{"wrap before midnight", Window{Start: "22:00", End: "02:00", Timezone: "UTC"}, at(t, "23:30", time.UTC), true},
+		{"wrap after midnight", Window{Start: "22:00", End: "02:00", Timezone: "UTC"}, at(t, "01:30", time.UTC), true},
+		{"wrap outside", Window{Start: "22:00", End: "02:00", Timezone: "UTC"}, at(t, "12:00", time.UTC), false},
+		// 跨零点窗口零点后的部分归属前一天：周五 01:30 属于周四的窗口
+		{"wrap belongs to previous day", Window{Days: []string{"Thu"}, Start: "22:00", End: "02:00", Timezone: "UTC"}, at(t, "01:30", time.UTC), true},
+		{"wrap not this day", Window{Days: []string{"Fri"}, Start: "22:00", End: "02:00", Timezone: "UTC"}, at(t, "01:30", time.UTC), false},
+		{"wrap same day evening", Window{Days: []string{"Fri"}, Start: "22:00", End: "02:00", Timezone: "UTC"}, at(t, "22:30", time.UTC), true},
+		// UTC 周四 19:00 为上海周五 03:00
+		{"timezone", Window{Days: []string{"Fri"}, Start: "02:00", End: "06:00", Timezone: "Asia/Shanghai"}, at(t, "03:00", shanghai).UTC(), true},
+		{"timezone outside", Window{Start: "02:00", End: "06:00", Timezone: "Asia/Shanghai"}, at(t, "03:00", time.UTC), false},
+	}
+	for _, tc := range cases {
+		got, err := tc.w.contains(tc.now)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: contains(%s) = %v, want %v", tc.name, tc.now.Format(time.RFC3339), got, tc.want)
+		}
+	}
+}
+
+func TestWindowInvalid(t *testing.T) {
+	for _, w := range []Window{
+		{Start: "2am", End: "06:00"},
+		{Start: "02:00", End: "25:00"},
+		{Start: "02:00", End: "06:00", Timezone: "Mars/Olympus"},
+	} {
+		if _, err := w.contains(time.Now()); err == nil {
+			t.Errorf("window %+v accepted", w)
+		}
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	target := Target{App: "game-prod-1", Project: "prod", DestServer: "https://prod.example.com", DestName: "prod-sh"}
+	cases := []struct {
+		name string
+		rule Rule
+		want bool
+	}{
+		{"empty rule matches all", Rule{}, true},
+		{"app glob", Rule{Apps: []string{"game-*"}}, true},
+		{"app glob miss", Rule{Apps: []string{"web-*"}}, false},
+		{"any app pattern", Rule{Apps: []string{"web-*", "game-prod-?"}}, true},
+		{"project", Rule{Projects: []string{"prod"}}, true},
+		{"project miss", Rule{Projects: []string{"staging"}}, false},
+		{"destination server", Rule{Destinations: []string{"https://prod.example.com"}}, true},
+		{"destination name glob", Rule{Destinations: []string{"prod-*"}}, true},
+		{"destination miss", Rule{Destinations: []string{"https://staging.example.com", "staging-*"}}, false},
+		{"all fields", Rule{Apps: []string{"game-*"}, Projects: []string{"prod"}, Destinations: []string{"prod-*"}}, true},
+		{"one field misses", Rule{Apps: []string{"game-*"}, Projects: []string{"staging"}}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.rule.matches(target); got != tc.want {
+			t.Errorf("%s: matches = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	p := &Policy{Rules: []Rule{
+		{
+			Name:            "prod-games",
+			Apps:            []string{"game-*"},
+			AllowedSubjects: []string{"alice", "ops-*"},
+			Windows:         []Window{{Start: "02:00", End: "06:00", Timezone: "UTC"}},
+			MaxWorkloads:    5,
+		},
+		{Apps: []string{"web-*"}, AllowedSubjects: []string{"nobody"}},
+	}}
+	inWindow := at(t, "03:00", time.UTC)
+	cases := []struct {
+		name   string
+		target Target
+		now    time.Time
+		want   int
+	}{
+		{"allowed", Target{App: "game-a", Subject: "ops-bob", Workloads: 3}, inWindow, 0},
+		{"subject", Target{App: "game-a", Subject: "mallory", Workloads: 3}, inWindow, 1},
+		{"window", Target{App: "game-a", Subject: "alice", Workloads: 3}, at(t, "12:00", time.UTC), 1},
+		{"workloads", Target{App: "game-a", Subject: "alice", Workloads: 6}, inWindow, 1},
+		{"workloads not applicable", Target{App: "game-a", Subject: "alice", Workloads: -1}, inWindow, 0},
+		{"all violations", Target{App: "game-a", Subject: "mallory", Workloads: 6}, at(t, "12:00", time.UTC), 3},
+		{"unnamed rule", Target{App: "web-a", Subject: "alice"}, inWindow, 1},
+		{"no rule matches", Target{App: "tools", Subject: "mallory", Workloads: 100}, at(t, "12:00", time.UTC), 0},
+	}
+	for _, tc := range cases {
+		got, err := p.Evaluate(tc.target, tc.now)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(got) != tc.want {
+			t.Errorf("%s: %d violation(s) %v, want %d", tc.name, len(got), got, tc.want)
+		}
+	}
+
+	got, _ := p.Evaluate(Target{App: "web-a", Subject: "alice"}, inWindow)
+	if len(got) == 1 && got[0].Rule != "unnamed" {
+		t.Errorf("rule name = %q, want unnamed", got[0].Rule)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p := &Policy{Rules: []Rule{{Name: "prod", AllowedSubjects: []string{"alice"}}}}
+	if err := p.Check(Target{App: "game", Subject: "alice"}, time.Now()); err != nil {
+		t.Fatalf("allowed subject denied: %v", err)
+	}
+	err := p.Check(Target{App: "game", Subject: "bob", Operation: "down"}, time.Now())
+	var denied *DeniedError
+	if !errors.As(err, &denied) {
+		t.Fatalf("Check error = %v, want *DeniedError", err)
+	}
+	if denied.Target.Subject != "bob" || len(denied.Violations) != 1 || denied.Violations[0].Rule != "prod" {
+		t.Errorf("denied = %+v", denied)
+	}
+
+	bad := &Policy{Rules: []Rule{{Windows: []Window{{Start: "x", End: "06:00"}}}}}
+	if err := bad.Check(Target{App: "game"}, time.Now()); err == nil || errors.As(err, &denied) {
+		t.Errorf("invalid window: error = %v, want a configuration error", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "policy.yaml")
+	if err := os.WriteFile(good, []byte("rules:\n  - name: prod\n    apps: [\"game-*\"]\n    maxWorkloads: 3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, file, err := Load(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file != good || len(p.Rules) != 1 || p.Rules[0].MaxWorkloads != 3 {
+		t.Errorf("Load = %+v, %q", p, file)
+	}
+
+	typo := filepath.Join(dir, "typo.yaml")
+	if err := os.WriteFile(typo, []byte("rules:\n  - name: prod\n    app: [\"game-*\"]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Load(typo); err == nil {
+		t.Error("unknown field accepted")
+	}
+	if _, _, err := Load(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("missing explicit policy file accepted")
+	}
+}
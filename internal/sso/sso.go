@@ -0,0 +1,182 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// DefaultPort argocd CLI 在 Dex 中注册的回调端口
+const DefaultPort = 8085
+
+// Options OIDC 授权码 + PKCE 登录参数
+type Options struct {
+	// Config 提供 ClientID、Scopes 与授权/令牌端点；RedirectURL 由 Login 设置
+	Config *oauth2.Config
+	// Port 本地回调端口，0 表示 DefaultPort
+	Port int
+	// HTTPClient 用于换取 token 的 HTTP 客户端（携带 TLS 设置），为空时使用默认客户端
+	HTTPClient *http.Client
+	// OpenURL 打开授权地址，为空时仅打印地址；测试中可替换为模拟浏览器的实现
+	OpenURL func(url string) error
+	// Out 输出提示信息
+	Out io.Writer
+}
+
+// Result 登录结果
+type Result struct {
+	IDToken string
+}
+
+// offlineAccessScope 申请 refresh token 的 scope
+const offlineAccessScope = "offline_access"
+
+// Login 执行授权码 + PKCE 流程：在本地端口接收回调，校验 state 后用 code_verifier 换取 id_token。
+// 会话只保存 id_token，过期后重新登录，因此不申请离线访问（refresh token）。
+func Login(ctx context.Context, opts Options) (*Result, error) {
+	if opts.Config == nil {
+		return nil, errors.New("缺少 OIDC 配置")
+	}
+	port := opts.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+	out := opts.Out
+	if out == nil {
+		out = io.Discard
+	}
+	conf := *opts.Config
+	conf.RedirectURL = fmt.Sprintf("http://localhost:%d/auth/callback", port)
+	conf.Scopes = nil
+	for _, s := range opts.Config.Scopes {
+		if s != offlineAccessScope {
+			conf.Scopes = append(conf.Scopes, s)
+		}
+	}
+
+	state, err := randomString(24)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	exchangeCtx := ctx
+	if opts.HTTPClient != nil {
+		exchangeCtx = context.WithValue(ctx, oauth2.HTTPClient, opts.HTTPClient)
+	}
+
+	type outcome struct {
+		res *Result
+		err error
+	}
+	done := make(chan outcome, 1)
+	finish := func(o outcome) {
+		select {
+		case done <- o:
+		default:
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/callback", func(w http.ResponseWriter, r *http.Request) {
+		fail := func(msg string) {
+			http.Error(w, html.EscapeString(msg), http.StatusBadRequest)
+			finish(outcome{err: errors.New(msg)})
+		}
+		if e := r.FormValue("error"); e != "" {
+			fail(fmt.Sprintf("%s: %s", e, r.FormValue("error_description")))
+			return
+		}
+		if r.FormValue("state") != state {
+			fail("state 不匹配，拒绝回调")
+			return
+		}
+		code := r.FormValue("code")
+		if code == "" {
+			fail("回调中缺少 code")
+			return
+		}
+		tok, err := conf.Exchange(exchangeCtx, code, oauth2.SetAuthURLParam("code_verifier", verifier))
+		if err != nil {
+			fail(fmt.Sprintf("换取 token 失败: %v", err))
+			return
+		}
+		idToken, ok := tok.Extra("id_token").(string)
+		if !ok || idToken == "" {
+			fail("token 响应中缺少 id_token")
+			return
+		}
+		fmt.Fprint(w, "<p>Authentication successful, you can now return to the CLI.</p>")
+		finish(outcome{res: &Result{IDToken: idToken}})
+	})
+
+	ln, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("监听回调端口 %d 失败: %w", port, err)
+	}
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			finish(outcome{err: fmt.Errorf("回调服务异常: %w", err)})
+		}
+	}()
+	defer func() {
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(sctx)
+	}()
+
+	authURL := conf.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+	fmt.Fprintf(out, "[sso] open the following URL to log in:\n%s\n", authURL)
+	if opts.OpenURL != nil {
+		if err := opts.OpenURL(authURL); err != nil {
+			fmt.Fprintf(out, "[sso] failed to open browser: %v\n", err)
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case o := <-done:
+		return o.res, o.err
+	}
+}
+
+// OpenBrowser 使用系统默认浏览器打开地址
+func OpenBrowser(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,179 @@
+package sso
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const testIDToken = "header.payload.signature"
+
+// fakeProvider 本地 OIDC 提供方：发现文档、授权端点（直接重定向回回调）与校验 PKCE 的令牌端点
+type fakeProvider struct {
+	srv *httptest.Server
+
+	mu         sync.Mutex
+	challenges map[string]string // code -> code_challenge
+	authQuery  url.Values
+	exchanges  int
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+	p := &fakeProvider{challenges: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.srv.URL,
+			"authorization_endpoint":                p.srv.URL + "/auth",
+			"token_endpoint":                        p.srv.URL + "/token",
+			"jwks_uri":                              p.srv.URL + "/keys",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+			"scopes_supported":                      []string{"openid", "profile", "email", "groups", "offline_access"},
+		})
+	})
+	mux.HandleFunc("/auth", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		code := "code-" + q.Get("state")
+		p.mu.Lock()
+		p.authQuery = q
+		p.challenges[code] = q.Get("code_challenge")
+		p.mu.Unlock()
+		redirect := q.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+		http.Redirect(w, r, redirect, http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		p.exchanges++
+		challenge, ok := p.challenges[r.FormValue("code")]
+		delete(p.challenges, r.FormValue("code"))
+		p.mu.Unlock()
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("grant_type") != "authorization_code" || !ok ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     testIDToken,
+		})
+	})
+	p.srv = httptest.NewTLSServer(mux)
+	t.Cleanup(p.srv.Close)
+	return p
+}
+
+// oauth2Config 与 apiclient.OIDCConfig 相同：经发现文档得到端点，提供方支持时追加 offline_access
+func (p *fakeProvider) oauth2Config(t *testing.T) *oauth2.Config {
+	t.Helper()
+	provider, err := oidc.NewProvider(oidc.ClientContext(context.Background(), p.srv.Client()), p.srv.URL)
+	if err != nil {
+		t.Fatalf("discovery failed: %v", err)
+	}
+	return &oauth2.Config{
+		ClientID: "argo-cd-cli",
+		Scopes:   []string{oidc.ScopeOpenID, "profile", "email", "groups", oidc.ScopeOfflineAccess},
+		Endpoint: provider.Endpoint(),
+	}
+}
+
+// browser 模拟浏览器：访问授权地址并跟随重定向到本地回调，rewrite 可在访问前篡改地址
+func (p *fakeProvider) browser(rewrite func(*url.URL)) func(string) error {
+	return func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		if rewrite != nil {
+			rewrite(u)
+		}
+		resp, err := p.srv.Client().Get(u.String())
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestLoginPKCE(t *testing.T) {
+	p := newFakeProvider(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := Login(ctx, Options{
+		Config:     p.oauth2Config(t),
+		Port:       freePort(t),
+		HTTPClient: p.srv.Client(),
+		OpenURL:    p.browser(nil),
+	})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if res.IDToken != testIDToken {
+		t.Fatalf("IDToken = %q, want %q", res.IDToken, testIDToken)
+	}
+	p.mu.Lock()
+	q := p.authQuery
+	p.mu.Unlock()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("authorization request without S256 challenge: %v", q)
+	}
+	if strings.Contains(q.Get("scope"), oidc.ScopeOfflineAccess) || q.Get("access_type") != "" {
+		t.Fatalf("authorization request asks for offline access: %v", q)
+	}
+}
+
+func TestLoginStateMismatch(t *testing.T) {
+	p := newFakeProvider(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := Login(ctx, Options{
+		Config:     p.oauth2Config(t),
+		Port:       freePort(t),
+		HTTPClient: p.srv.Client(),
+		OpenURL: p.browser(func(u *url.URL) {
+			q := u.Query()
+			q.Set("state", "forged")
+			u.RawQuery = q.Encode()
+		}),
+	})
+	if err == nil || !strings.Contains(err.Error(), "state") {
+		t.Fatalf("Login error = %v, want state mismatch", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.exchanges != 0 {
+		t.Fatalf("token endpoint called %d times after state mismatch", p.exchanges)
+	}
+}